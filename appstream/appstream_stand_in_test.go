@@ -0,0 +1,679 @@
+package appstream
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/private/protocol/json/jsonutil"
+	"github.com/aws/aws-sdk-go/service/appstream"
+)
+
+// testAppstreamServer is a stand-in for the AppStream API that keeps fleets, image builders,
+// stacks, themes and tags in memory, enough to run the resource CRUD functions without AWS.
+// It also answers the S3 object requests of the theme images.
+type testAppstreamServer struct {
+	*httptest.Server
+
+	mu            sync.Mutex
+	fleets        map[string]*appstream.Fleet
+	imageBuilders map[string]*appstream.ImageBuilder
+	stacks        map[string]*appstream.Stack
+	themes        map[string]*appstreamTheme
+	tags          map[string]map[string]*string
+	// objects maps bucket/key to the content of the S3 objects.
+	objects map[string][]byte
+	// associations maps stack names to the names of their fleets.
+	associations map[string][]string
+	calls        map[string]int
+	// pageSize limits the items of paginated list responses, 0 returns everything.
+	pageSize int
+	// fail makes the named operation return a server error.
+	fail map[string]bool
+	// fleetErrors are reported by the fleet created with the given name, which then gets
+	// no available instances.
+	fleetErrors map[string][]*appstream.FleetError
+}
+
+func newTestAppstreamServer(t *testing.T) *testAppstreamServer {
+	t.Helper()
+
+	s := &testAppstreamServer{
+		fleets:        make(map[string]*appstream.Fleet),
+		imageBuilders: make(map[string]*appstream.ImageBuilder),
+		stacks:        make(map[string]*appstream.Stack),
+		themes:        make(map[string]*appstreamTheme),
+		tags:          make(map[string]map[string]*string),
+		objects:       make(map[string][]byte),
+		associations:  make(map[string][]string),
+		calls:         make(map[string]int),
+		fail:          make(map[string]bool),
+		fleetErrors:   make(map[string][]*appstream.FleetError),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// client returns a provider client whose AppStream and S3 endpoints are the stand-in server.
+func (s *testAppstreamServer) client(t *testing.T) *AWSClient {
+	t.Helper()
+
+	config := &Config{
+		AccessKey:               "test",
+		SecretKey:               "test",
+		Region:                  "eu-west-1",
+		Endpoints:               map[string]string{"appstream": s.URL, "s3": s.URL},
+		MaxRetries:              0,
+		S3ForcePathStyle:        true,
+		SkipCredsValidation:     true,
+		SkipMetadataApiCheck:    true,
+		SkipRequestingAccountId: true,
+	}
+	client, err := config.Client()
+	if err != nil {
+		t.Fatalf("error building client: %s", err)
+	}
+	return client.(*AWSClient)
+}
+
+func (s *testAppstreamServer) addFleet(fleet *appstream.Fleet) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.putFleet(fleet)
+}
+
+func (s *testAppstreamServer) putFleet(fleet *appstream.Fleet) {
+	if fleet.Arn == nil {
+		fleet.Arn = aws.String("arn:aws:appstream:eu-west-1:123456789012:fleet/" + aws.StringValue(fleet.Name))
+	}
+	s.fleets[aws.StringValue(fleet.Name)] = fleet
+	s.tags[aws.StringValue(fleet.Arn)] = make(map[string]*string)
+}
+
+func (s *testAppstreamServer) addImageBuilder(builder *appstream.ImageBuilder) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.putImageBuilder(builder)
+}
+
+func (s *testAppstreamServer) putImageBuilder(builder *appstream.ImageBuilder) {
+	if builder.Arn == nil {
+		builder.Arn = aws.String("arn:aws:appstream:eu-west-1:123456789012:image-builder/" + aws.StringValue(builder.Name))
+	}
+	s.imageBuilders[aws.StringValue(builder.Name)] = builder
+	s.tags[aws.StringValue(builder.Arn)] = make(map[string]*string)
+}
+
+func (s *testAppstreamServer) addStack(stack *appstream.Stack) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if stack.Arn == nil {
+		stack.Arn = aws.String("arn:aws:appstream:eu-west-1:123456789012:stack/" + aws.StringValue(stack.Name))
+	}
+	s.stacks[aws.StringValue(stack.Name)] = stack
+	s.tags[aws.StringValue(stack.Arn)] = make(map[string]*string)
+}
+
+func (s *testAppstreamServer) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.Header.Get("X-Amz-Target") == "" {
+		s.handleS3(w, r)
+		return
+	}
+
+	op := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "PhotonAdminProxyService.")
+	s.calls[op]++
+	if s.fail[op] {
+		s.writeError(w, http.StatusInternalServerError, "InternalServiceError", op+" failed")
+		return
+	}
+
+	var out interface{}
+	var err error
+	switch op {
+	case "DescribeFleets":
+		out, err = s.describeFleets(r)
+	case "CreateFleet":
+		out, err = s.createFleet(r)
+	case "UpdateFleet":
+		out, err = s.updateFleet(r)
+	case "StartFleet", "StopFleet":
+		in := &appstream.StartFleetInput{}
+		if err = jsonutil.UnmarshalJSON(in, r.Body); err == nil {
+			fleet, ok := s.fleets[aws.StringValue(in.Name)]
+			if !ok {
+				err = fmt.Errorf("fleet %s not found", aws.StringValue(in.Name))
+				break
+			}
+			// Transitions complete at once so waits do not sleep.
+			fleet.State = aws.String(appstream.FleetStateRunning)
+			if op == "StopFleet" {
+				fleet.State = aws.String(appstream.FleetStateStopped)
+			}
+			out = &appstream.StartFleetOutput{}
+		}
+	case "DescribeImageBuilders":
+		in := &appstream.DescribeImageBuildersInput{}
+		if err = jsonutil.UnmarshalJSON(in, r.Body); err == nil {
+			builders := make([]*appstream.ImageBuilder, 0)
+			for _, name := range in.Names {
+				if builder, ok := s.imageBuilders[aws.StringValue(name)]; ok {
+					builders = append(builders, builder)
+				}
+			}
+			if len(in.Names) == 0 {
+				for _, builder := range s.imageBuilders {
+					builders = append(builders, builder)
+				}
+			}
+			out = &appstream.DescribeImageBuildersOutput{ImageBuilders: builders}
+		}
+	case "CreateImageBuilder":
+		in := &appstream.CreateImageBuilderInput{}
+		if err = jsonutil.UnmarshalJSON(in, r.Body); err == nil {
+			if _, ok := s.imageBuilders[aws.StringValue(in.Name)]; ok {
+				err = fmt.Errorf("image builder %s already exists", aws.StringValue(in.Name))
+				break
+			}
+			builder := &appstream.ImageBuilder{
+				Name:         in.Name,
+				ImageArn:     in.ImageArn,
+				InstanceType: in.InstanceType,
+				State:        aws.String(appstream.ImageBuilderStateRunning),
+			}
+			s.putImageBuilder(builder)
+			for k, v := range in.Tags {
+				s.tags[aws.StringValue(builder.Arn)][k] = v
+			}
+			out = &appstream.CreateImageBuilderOutput{ImageBuilder: builder}
+		}
+	case "StartImageBuilder", "StopImageBuilder":
+		in := &appstream.StartImageBuilderInput{}
+		if err = jsonutil.UnmarshalJSON(in, r.Body); err == nil {
+			builder, ok := s.imageBuilders[aws.StringValue(in.Name)]
+			if !ok {
+				err = fmt.Errorf("image builder %s not found", aws.StringValue(in.Name))
+				break
+			}
+			builder.State = aws.String(appstream.ImageBuilderStateRunning)
+			if op == "StopImageBuilder" {
+				builder.State = aws.String(appstream.ImageBuilderStateStopped)
+			}
+			out = &appstream.StartImageBuilderOutput{ImageBuilder: builder}
+		}
+	case "AssociateFleet":
+		in := &appstream.AssociateFleetInput{}
+		if err = jsonutil.UnmarshalJSON(in, r.Body); err == nil {
+			stack := aws.StringValue(in.StackName)
+			s.associations[stack] = append(s.associations[stack], aws.StringValue(in.FleetName))
+			out = &appstream.AssociateFleetOutput{}
+		}
+	case "DisassociateFleet":
+		in := &appstream.DisassociateFleetInput{}
+		if err = jsonutil.UnmarshalJSON(in, r.Body); err == nil {
+			stack := aws.StringValue(in.StackName)
+			fleets := make([]string, 0)
+			for _, fleet := range s.associations[stack] {
+				if fleet != aws.StringValue(in.FleetName) {
+					fleets = append(fleets, fleet)
+				}
+			}
+			s.associations[stack] = fleets
+			out = &appstream.DisassociateFleetOutput{}
+		}
+	case "ListAssociatedStacks":
+		in := &appstream.ListAssociatedStacksInput{}
+		if err = jsonutil.UnmarshalJSON(in, r.Body); err == nil {
+			names := make([]string, 0)
+			for stack, fleets := range s.associations {
+				for _, fleet := range fleets {
+					if fleet == aws.StringValue(in.FleetName) {
+						names = append(names, stack)
+					}
+				}
+			}
+			sort.Strings(names)
+			out = &appstream.ListAssociatedStacksOutput{Names: aws.StringSlice(names)}
+		}
+	case "DescribeStacks":
+		out, err = s.describeStacks(r)
+	case "UpdateStack":
+		out, err = s.updateStack(r)
+	case "ListAssociatedFleets":
+		in := &appstream.ListAssociatedFleetsInput{}
+		if err = jsonutil.UnmarshalJSON(in, r.Body); err == nil {
+			out = &appstream.ListAssociatedFleetsOutput{Names: aws.StringSlice(s.associations[aws.StringValue(in.StackName)])}
+		}
+	case "CreateThemeForStack":
+		out, err = s.createThemeForStack(r)
+	case "DescribeThemeForStack":
+		in := &describeThemeForStackInput{}
+		if err = jsonutil.UnmarshalJSON(in, r.Body); err == nil {
+			theme, ok := s.themes[aws.StringValue(in.StackName)]
+			if !ok {
+				s.writeError(w, http.StatusBadRequest, appstream.ErrCodeResourceNotFoundException, "theme not found")
+				return
+			}
+			out = &describeThemeForStackOutput{Theme: theme}
+		}
+	case "UpdateThemeForStack":
+		out, err = s.updateThemeForStack(r)
+	case "DeleteThemeForStack":
+		in := &deleteThemeForStackInput{}
+		if err = jsonutil.UnmarshalJSON(in, r.Body); err == nil {
+			if _, ok := s.themes[aws.StringValue(in.StackName)]; !ok {
+				s.writeError(w, http.StatusBadRequest, appstream.ErrCodeResourceNotFoundException, "theme not found")
+				return
+			}
+			delete(s.themes, aws.StringValue(in.StackName))
+			out = &deleteThemeForStackOutput{}
+		}
+	case "ListTagsForResource":
+		in := &appstream.ListTagsForResourceInput{}
+		if err = jsonutil.UnmarshalJSON(in, r.Body); err == nil {
+			out = &appstream.ListTagsForResourceOutput{Tags: s.tags[aws.StringValue(in.ResourceArn)]}
+		}
+	case "TagResource":
+		in := &appstream.TagResourceInput{}
+		if err = jsonutil.UnmarshalJSON(in, r.Body); err == nil {
+			for k, v := range in.Tags {
+				s.tags[aws.StringValue(in.ResourceArn)][k] = v
+			}
+			out = &appstream.TagResourceOutput{}
+		}
+	case "UntagResource":
+		in := &appstream.UntagResourceInput{}
+		if err = jsonutil.UnmarshalJSON(in, r.Body); err == nil {
+			for _, k := range in.TagKeys {
+				delete(s.tags[aws.StringValue(in.ResourceArn)], aws.StringValue(k))
+			}
+			out = &appstream.UntagResourceOutput{}
+		}
+	default:
+		err = fmt.Errorf("unsupported operation %q", op)
+	}
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, appstream.ErrCodeInvalidParameterCombinationException, err.Error())
+		return
+	}
+
+	body, err := jsonutil.BuildJSON(out)
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, "InternalServiceError", err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+	w.Write(body)
+}
+
+func (s *testAppstreamServer) writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `{"__type":%q,"message":%q}`, code, message)
+}
+
+func (s *testAppstreamServer) describeFleets(r *http.Request) (interface{}, error) {
+	in := &appstream.DescribeFleetsInput{}
+	if err := jsonutil.UnmarshalJSON(in, r.Body); err != nil {
+		return nil, err
+	}
+
+	out := &appstream.DescribeFleetsOutput{Fleets: make([]*appstream.Fleet, 0)}
+	if len(in.Names) == 0 {
+		names := make([]string, 0, len(s.fleets))
+		for name := range s.fleets {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		start, end, next := s.page(len(names), aws.StringValue(in.NextToken))
+		for _, name := range names[start:end] {
+			out.Fleets = append(out.Fleets, s.fleets[name])
+		}
+		out.NextToken = next
+		return out, nil
+	}
+	for _, name := range in.Names {
+		if fleet, ok := s.fleets[aws.StringValue(name)]; ok {
+			out.Fleets = append(out.Fleets, fleet)
+		}
+	}
+	return out, nil
+}
+
+// createFleet adds a STOPPED fleet with the requested capacity and tags.
+func (s *testAppstreamServer) createFleet(r *http.Request) (interface{}, error) {
+	in := &appstream.CreateFleetInput{}
+	if err := jsonutil.UnmarshalJSON(in, r.Body); err != nil {
+		return nil, err
+	}
+	if _, ok := s.fleets[aws.StringValue(in.Name)]; ok {
+		return nil, fmt.Errorf("fleet %s already exists", aws.StringValue(in.Name))
+	}
+
+	fleet := &appstream.Fleet{
+		Name:                     in.Name,
+		Description:              in.Description,
+		DisplayName:              in.DisplayName,
+		FleetType:                in.FleetType,
+		IamRoleArn:               in.IamRoleArn,
+		ImageArn:                 in.ImageArn,
+		InstanceType:             in.InstanceType,
+		MaxUserDurationInSeconds: in.MaxUserDurationInSeconds,
+		State:                    aws.String(appstream.FleetStateStopped),
+	}
+	if in.ComputeCapacity != nil {
+		fleet.ComputeCapacityStatus = &appstream.ComputeCapacityStatus{
+			Available: in.ComputeCapacity.DesiredInstances,
+			Desired:   in.ComputeCapacity.DesiredInstances,
+			InUse:     aws.Int64(0),
+			Running:   in.ComputeCapacity.DesiredInstances,
+		}
+	}
+	if errs, ok := s.fleetErrors[aws.StringValue(in.Name)]; ok {
+		fleet.FleetErrors = errs
+		if fleet.ComputeCapacityStatus != nil {
+			fleet.ComputeCapacityStatus.Available = aws.Int64(0)
+		}
+	}
+	s.putFleet(fleet)
+	for k, v := range in.Tags {
+		s.tags[aws.StringValue(fleet.Arn)][k] = v
+	}
+	return &appstream.CreateFleetOutput{Fleet: fleet}, nil
+}
+
+// updateFleet applies the fields of an UpdateFleet request that are set.
+func (s *testAppstreamServer) updateFleet(r *http.Request) (interface{}, error) {
+	in := &appstream.UpdateFleetInput{}
+	if err := jsonutil.UnmarshalJSON(in, r.Body); err != nil {
+		return nil, err
+	}
+
+	fleet, ok := s.fleets[aws.StringValue(in.Name)]
+	if !ok {
+		return nil, fmt.Errorf("fleet %s not found", aws.StringValue(in.Name))
+	}
+	// A running fleet only accepts capacity, image, display name and timeout changes.
+	if aws.StringValue(fleet.State) != appstream.FleetStateStopped && (in.Description != nil || in.DomainJoinInfo != nil ||
+		in.EnableDefaultInternetAccess != nil || in.IamRoleArn != nil || in.InstanceType != nil ||
+		in.MaxUserDurationInSeconds != nil || in.VpcConfig != nil || len(in.AttributesToDelete) > 0) {
+		return nil, fmt.Errorf("fleet %s is %s, stop it to update these attributes", aws.StringValue(in.Name), aws.StringValue(fleet.State))
+	}
+	if in.ComputeCapacity != nil {
+		fleet.ComputeCapacityStatus.Desired = in.ComputeCapacity.DesiredInstances
+	}
+	if in.Description != nil {
+		fleet.Description = in.Description
+	}
+	if in.DisplayName != nil {
+		fleet.DisplayName = in.DisplayName
+	}
+	if in.ImageArn != nil {
+		fleet.ImageArn = in.ImageArn
+	}
+	if in.IamRoleArn != nil {
+		fleet.IamRoleArn = in.IamRoleArn
+	}
+	if in.InstanceType != nil {
+		fleet.InstanceType = in.InstanceType
+	}
+	if in.MaxUserDurationInSeconds != nil {
+		fleet.MaxUserDurationInSeconds = in.MaxUserDurationInSeconds
+	}
+	return &appstream.UpdateFleetOutput{Fleet: fleet}, nil
+}
+
+func (s *testAppstreamServer) describeStacks(r *http.Request) (interface{}, error) {
+	in := &appstream.DescribeStacksInput{}
+	if err := jsonutil.UnmarshalJSON(in, r.Body); err != nil {
+		return nil, err
+	}
+
+	out := &appstream.DescribeStacksOutput{Stacks: make([]*appstream.Stack, 0)}
+	if len(in.Names) == 0 {
+		names := make([]string, 0, len(s.stacks))
+		for name := range s.stacks {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		start, end, next := s.page(len(names), aws.StringValue(in.NextToken))
+		for _, name := range names[start:end] {
+			out.Stacks = append(out.Stacks, s.stacks[name])
+		}
+		out.NextToken = next
+		return out, nil
+	}
+	for _, name := range in.Names {
+		if stack, ok := s.stacks[aws.StringValue(name)]; ok {
+			out.Stacks = append(out.Stacks, stack)
+		}
+	}
+	return out, nil
+}
+
+// page returns the bounds of the page starting at token and the token of the next page.
+func (s *testAppstreamServer) page(n int, token string) (int, int, *string) {
+	start, _ := strconv.Atoi(token)
+	if s.pageSize == 0 || start+s.pageSize >= n {
+		return start, n, nil
+	}
+	return start, start + s.pageSize, aws.String(strconv.Itoa(start + s.pageSize))
+}
+
+// updateStack applies an UpdateStack request the way the API does: fields left out are
+// kept, storage connectors are merged by type, user settings by action and
+// AttributesToDelete clears attributes.
+func (s *testAppstreamServer) updateStack(r *http.Request) (interface{}, error) {
+	in := &appstream.UpdateStackInput{}
+	if err := jsonutil.UnmarshalJSON(in, r.Body); err != nil {
+		return nil, err
+	}
+
+	stack, ok := s.stacks[aws.StringValue(in.Name)]
+	if !ok {
+		return nil, fmt.Errorf("stack %s not found", aws.StringValue(in.Name))
+	}
+
+	if in.AccessEndpoints != nil {
+		stack.AccessEndpoints = in.AccessEndpoints
+	}
+	if in.Description != nil {
+		stack.Description = in.Description
+	}
+	if in.DisplayName != nil {
+		stack.DisplayName = in.DisplayName
+	}
+	if in.EmbedHostDomains != nil {
+		stack.EmbedHostDomains = in.EmbedHostDomains
+	}
+	if in.FeedbackURL != nil {
+		stack.FeedbackURL = in.FeedbackURL
+	}
+	if in.RedirectURL != nil {
+		stack.RedirectURL = in.RedirectURL
+	}
+	for _, sc := range in.StorageConnectors {
+		replaced := false
+		for i, existing := range stack.StorageConnectors {
+			if aws.StringValue(existing.ConnectorType) == aws.StringValue(sc.ConnectorType) {
+				stack.StorageConnectors[i] = sc
+				replaced = true
+			}
+		}
+		if !replaced {
+			stack.StorageConnectors = append(stack.StorageConnectors, sc)
+		}
+	}
+	if in.StreamingExperienceSettings != nil {
+		stack.StreamingExperienceSettings = in.StreamingExperienceSettings
+	}
+	for _, us := range in.UserSettings {
+		// Enabled clipboard actions report the default limit when none is set.
+		if isClipboardAction(aws.StringValue(us.Action)) && aws.StringValue(us.Permission) == appstream.PermissionEnabled && us.MaximumLength == nil {
+			us.MaximumLength = aws.Int64(clipboardDefaultMaximumLength)
+		}
+		replaced := false
+		for i, existing := range stack.UserSettings {
+			if aws.StringValue(existing.Action) == aws.StringValue(us.Action) {
+				stack.UserSettings[i] = us
+				replaced = true
+			}
+		}
+		if !replaced {
+			stack.UserSettings = append(stack.UserSettings, us)
+		}
+	}
+
+	for _, attr := range aws.StringValueSlice(in.AttributesToDelete) {
+		switch attr {
+		case appstream.StackAttributeAccessEndpoints:
+			stack.AccessEndpoints = nil
+		case appstream.StackAttributeEmbedHostDomains:
+			stack.EmbedHostDomains = nil
+		case appstream.StackAttributeFeedbackUrl:
+			stack.FeedbackURL = nil
+		case appstream.StackAttributeRedirectUrl:
+			stack.RedirectURL = nil
+		case appstream.StackAttributeStorageConnectors:
+			stack.StorageConnectors = nil
+		case appstream.StackAttributeStorageConnectorHomefolders, appstream.StackAttributeStorageConnectorGoogleDrive, appstream.StackAttributeStorageConnectorOneDrive:
+			connectors := make([]*appstream.StorageConnector, 0)
+			for _, sc := range stack.StorageConnectors {
+				if "STORAGE_CONNECTOR_"+aws.StringValue(sc.ConnectorType) != attr {
+					connectors = append(connectors, sc)
+				}
+			}
+			stack.StorageConnectors = connectors
+		case appstream.StackAttributeStreamingExperienceSettings:
+			stack.StreamingExperienceSettings = nil
+		default:
+			return nil, fmt.Errorf("unsupported attribute to delete %q", attr)
+		}
+	}
+
+	return &appstream.UpdateStackOutput{Stack: stack}, nil
+}
+
+// handleS3 stores and deletes objects for path-style PutObject and DeleteObject requests.
+func (s *testAppstreamServer) handleS3(w http.ResponseWriter, r *http.Request) {
+	op := map[string]string{http.MethodPut: "PutObject", http.MethodDelete: "DeleteObject"}[r.Method]
+	s.calls[op]++
+	if op == "" || s.fail[op] {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, "<Error><Code>InternalError</Code><Message>%s %s failed</Message></Error>", r.Method, r.URL.Path)
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, "/")
+	if op == "PutObject" {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		s.objects[name] = body
+		return
+	}
+	delete(s.objects, name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// themeImageURL checks an image was uploaded and returns the URL the theme serves it from.
+func (s *testAppstreamServer) themeImageURL(stackName string, location *appstreamS3Location) (*string, error) {
+	name := aws.StringValue(location.S3Bucket) + "/" + aws.StringValue(location.S3Key)
+	if _, ok := s.objects[name]; !ok {
+		return nil, fmt.Errorf("s3://%s not found", name)
+	}
+	return aws.String("https://appstream.test/themes/" + stackName + "/" + aws.StringValue(location.S3Key)), nil
+}
+
+// createThemeForStack adds an ENABLED theme to an existing stack.
+func (s *testAppstreamServer) createThemeForStack(r *http.Request) (interface{}, error) {
+	in := &createThemeForStackInput{}
+	if err := jsonutil.UnmarshalJSON(in, r.Body); err != nil {
+		return nil, err
+	}
+	stackName := aws.StringValue(in.StackName)
+	if _, ok := s.stacks[stackName]; !ok {
+		return nil, fmt.Errorf("stack %s not found", stackName)
+	}
+	if _, ok := s.themes[stackName]; ok {
+		return nil, fmt.Errorf("theme for stack %s already exists", stackName)
+	}
+
+	theme := &appstreamTheme{
+		StackName:        in.StackName,
+		State:            aws.String(themeStateEnabled),
+		ThemeFooterLinks: in.FooterLinks,
+		ThemeStyling:     in.ThemeStyling,
+		ThemeTitleText:   in.TitleText,
+	}
+	var err error
+	if theme.ThemeOrganizationLogoURL, err = s.themeImageURL(stackName, in.OrganizationLogoS3Location); err != nil {
+		return nil, err
+	}
+	if theme.ThemeFaviconURL, err = s.themeImageURL(stackName, in.FaviconS3Location); err != nil {
+		return nil, err
+	}
+	s.themes[stackName] = theme
+	return &createThemeForStackOutput{Theme: theme}, nil
+}
+
+// updateThemeForStack applies the fields of an UpdateThemeForStack request that are set.
+func (s *testAppstreamServer) updateThemeForStack(r *http.Request) (interface{}, error) {
+	in := &updateThemeForStackInput{}
+	if err := jsonutil.UnmarshalJSON(in, r.Body); err != nil {
+		return nil, err
+	}
+	stackName := aws.StringValue(in.StackName)
+	theme, ok := s.themes[stackName]
+	if !ok {
+		return nil, fmt.Errorf("theme for stack %s not found", stackName)
+	}
+
+	var err error
+	if in.OrganizationLogoS3Location != nil {
+		if theme.ThemeOrganizationLogoURL, err = s.themeImageURL(stackName, in.OrganizationLogoS3Location); err != nil {
+			return nil, err
+		}
+	}
+	if in.FaviconS3Location != nil {
+		if theme.ThemeFaviconURL, err = s.themeImageURL(stackName, in.FaviconS3Location); err != nil {
+			return nil, err
+		}
+	}
+	if in.FooterLinks != nil {
+		theme.ThemeFooterLinks = in.FooterLinks
+	}
+	if in.State != nil {
+		theme.State = in.State
+	}
+	if in.ThemeStyling != nil {
+		theme.ThemeStyling = in.ThemeStyling
+	}
+	if in.TitleText != nil {
+		theme.ThemeTitleText = in.TitleText
+	}
+	for _, attr := range aws.StringValueSlice(in.AttributesToDelete) {
+		if attr != themeAttributeFooterLinks {
+			return nil, fmt.Errorf("unsupported attribute to delete %q", attr)
+		}
+		theme.ThemeFooterLinks = nil
+	}
+	return &updateThemeForStackOutput{Theme: theme}, nil
+}
@@ -1,58 +1,61 @@
 package appstream
 
 import (
+	"fmt"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/aws/aws-sdk-go/service/applicationautoscaling"
 	"github.com/aws/aws-sdk-go/service/appstream"
 	"github.com/aws/aws-sdk-go/service/imagebuilder"
+	"github.com/aws/aws-sdk-go/service/s3"
 	awsbase "github.com/hashicorp/aws-sdk-go-base"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/logging"
-	"fmt"
 	"log"
 )
 
-
 type Config struct {
-        AccessKey     string
-        SecretKey     string
-        CredsFilename string
-        Profile       string
-        Token         string
-        Region        string
-        MaxRetries    int
-
-        AssumeRoleARN         string
-        AssumeRoleExternalID  string
-        AssumeRoleSessionName string
-        AssumeRolePolicy      string
-
-        AllowedAccountIds   []string
-        ForbiddenAccountIds []string
-
-        Endpoints        map[string]string
+	AccessKey     string
+	SecretKey     string
+	CredsFilename string
+	Profile       string
+	Token         string
+	Region        string
+	MaxRetries    int
+
+	AssumeRoleARN         string
+	AssumeRoleExternalID  string
+	AssumeRoleSessionName string
+	AssumeRolePolicy      string
+
+	AllowedAccountIds   []string
+	ForbiddenAccountIds []string
+
+	Endpoints         map[string]string
 	IgnoreTagPrefixes []string
 	IgnoreTags        []string
-        Insecure         bool
+	Insecure          bool
 
-        SkipCredsValidation     bool
-        SkipGetEC2Platforms     bool
-        SkipRegionValidation    bool
-        SkipRequestingAccountId bool
-        SkipMetadataApiCheck    bool
-        S3ForcePathStyle        bool
+	SkipCredsValidation     bool
+	SkipGetEC2Platforms     bool
+	SkipRegionValidation    bool
+	SkipRequestingAccountId bool
+	SkipMetadataApiCheck    bool
+	S3ForcePathStyle        bool
 
-        terraformVersion string
+	terraformVersion string
 }
 
 type AWSClient struct {
-	accountid          string
-	appstreamconn      *appstream.AppStream
-	dnsSuffix          string
-	imagebuilderconn   *imagebuilder.Imagebuilder
-	partition          string
-	region             string
-	supportedplatforms []string
-	terraformVersion   string
+	accountid                  string
+	applicationautoscalingconn *applicationautoscaling.ApplicationAutoScaling
+	appstreamconn              *appstream.AppStream
+	dnsSuffix                  string
+	imagebuilderconn           *imagebuilder.Imagebuilder
+	partition                  string
+	region                     string
+	s3conn                     *s3.S3
+	supportedplatforms         []string
+	terraformVersion           string
 }
 
 // PartitionHostname returns a hostname with the provider domain suffix for the partition
@@ -119,16 +122,18 @@ func (c *Config) Client() (interface{}, error) {
 	dnsSuffix := "amazonaws.com"
 	if p, ok := endpoints.PartitionForRegion(endpoints.DefaultPartitions(), c.Region); ok {
 		dnsSuffix = p.DNSSuffix()
-        }
-        
+	}
+
 	client := &AWSClient{
-		accountid:        accountID,
-		appstreamconn:    appstream.New(sess.Copy(&aws.Config{Endpoint: aws.String(c.Endpoints["appstream"])})),
-        dnsSuffix:        dnsSuffix,
-		imagebuilderconn: imagebuilder.New(sess.Copy(&aws.Config{Endpoint: aws.String(c.Endpoints["imagebuilder"])})),
-        partition:        partition,
-		region:           c.Region,
-		terraformVersion: c.terraformVersion,
+		accountid:                  accountID,
+		applicationautoscalingconn: applicationautoscaling.New(sess.Copy(&aws.Config{Endpoint: aws.String(c.Endpoints["applicationautoscaling"])})),
+		appstreamconn:              appstream.New(sess.Copy(&aws.Config{Endpoint: aws.String(c.Endpoints["appstream"])})),
+		dnsSuffix:                  dnsSuffix,
+		imagebuilderconn:           imagebuilder.New(sess.Copy(&aws.Config{Endpoint: aws.String(c.Endpoints["imagebuilder"])})),
+		partition:                  partition,
+		region:                     c.Region,
+		s3conn:                     s3.New(sess.Copy(&aws.Config{Endpoint: aws.String(c.Endpoints["s3"]), S3ForcePathStyle: aws.Bool(c.S3ForcePathStyle)})),
+		terraformVersion:           c.terraformVersion,
 	}
 	return client, nil
 }
@@ -0,0 +1,319 @@
+package appstream
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/appstream"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+// dataSourceAppstreamFleet looks up a fleet by name or ARN. Besides its configuration it
+// reports the health of the fleet: state, capacity status and fleet errors.
+func dataSourceAppstreamFleet() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceAppstreamFleetRead,
+
+		Schema: map[string]*schema.Schema{
+			"arn": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ExactlyOneOf: []string{"arn", "name"},
+			},
+
+			"compute_capacity_status": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"active_user_sessions": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"actual_user_sessions": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"available": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"available_user_sessions": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"desired": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"desired_user_sessions": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"in_use": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"running": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+					},
+				},
+			},
+
+			"created_time": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"deletion_protection": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+
+			"description": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"disconnect_timeout": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"display_name": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"domain_info": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"directory_name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"organizational_unit_distinguished_name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+
+			"enable_default_internet_access": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+
+			"fleet_errors": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"error_code": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"error_message": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+
+			"fleet_type": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"iam_role_arn": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"image_arn": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"image_name": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"instance_type": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"max_user_duration": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"name": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validateAppstreamName,
+			},
+
+			"stack_names": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+
+			"state": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"tags": {
+				Type:     schema.TypeMap,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+
+			"vpc_config": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"security_group_ids": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+						"subnet_ids": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceAppstreamFleetRead(d *schema.ResourceData, meta interface{}) error {
+	svc := meta.(*AWSClient).appstreamconn
+
+	name := d.Get("name").(string)
+	if v, ok := d.GetOk("arn"); ok {
+		n, err := appstreamNameFromArn(v.(string), "fleet")
+		if err != nil {
+			return err
+		}
+		name = n
+	}
+
+	v, err := describeFleet(svc, name)
+	if err != nil {
+		return err
+	}
+	if v == nil || (d.Get("arn").(string) != "" && aws.StringValue(v.Arn) != d.Get("arn").(string)) {
+		return fmt.Errorf("Appstream Fleet %s not found", name)
+	}
+
+	d.SetId(aws.StringValue(v.Name))
+	d.Set("arn", v.Arn)
+	d.Set("name", v.Name)
+	d.Set("description", v.Description)
+	d.Set("display_name", v.DisplayName)
+	d.Set("disconnect_timeout", v.DisconnectTimeoutInSeconds)
+	d.Set("enable_default_internet_access", v.EnableDefaultInternetAccess)
+	d.Set("fleet_type", v.FleetType)
+	d.Set("iam_role_arn", v.IamRoleArn)
+	d.Set("image_arn", v.ImageArn)
+	d.Set("image_name", v.ImageName)
+	d.Set("instance_type", v.InstanceType)
+	d.Set("max_user_duration", v.MaxUserDurationInSeconds)
+	d.Set("state", v.State)
+	if v.CreatedTime != nil {
+		d.Set("created_time", aws.TimeValue(v.CreatedTime).Format(time.RFC3339))
+	}
+
+	if err := d.Set("compute_capacity_status", flattenComputeCapacityStatus(v.ComputeCapacityStatus)); err != nil {
+		log.Printf("[ERROR] Error setting compute capacity status: %s", err)
+		return err
+	}
+
+	if err := d.Set("fleet_errors", flattenFleetErrors(v.FleetErrors)); err != nil {
+		log.Printf("[ERROR] Error setting fleet errors: %s", err)
+		return err
+	}
+
+	if err := d.Set("domain_info", flattenDomainJoinInfo(v.DomainJoinInfo)); err != nil {
+		log.Printf("[ERROR] Error setting domain info: %s", err)
+		return err
+	}
+
+	if err := d.Set("vpc_config", flattenVpcConfig(v.VpcConfig)); err != nil {
+		log.Printf("[ERROR] Error setting vpc config: %s", err)
+		return err
+	}
+
+	stacks, err := listAssociatedStacks(svc, aws.StringValue(v.Name))
+	if err != nil {
+		return err
+	}
+	if err := d.Set("stack_names", stacks); err != nil {
+		log.Printf("[ERROR] Error setting stack names: %s", err)
+		return err
+	}
+
+	tg, err := svc.ListTagsForResource(&appstream.ListTagsForResourceInput{
+		ResourceArn: v.Arn,
+	})
+	if err != nil {
+		log.Printf("[ERROR] Error listing fleet tags: %s", err)
+		return err
+	}
+
+	d.Set("deletion_protection", aws.StringValue(tg.Tags[deletionProtectionTagKey]) == "true")
+
+	if err := d.Set("tags", New(tg.Tags).IgnoreAWS().IgnoreProvider().Map()); err != nil {
+		log.Printf("[ERROR] Error setting fleet tags: %s", err)
+		return err
+	}
+
+	return nil
+}
+
+func flattenComputeCapacityStatus(computeCapacityStatus *appstream.ComputeCapacityStatus) []interface{} {
+	if computeCapacityStatus == nil {
+		return nil
+	}
+
+	return []interface{}{map[string]interface{}{
+		"active_user_sessions":    int(aws.Int64Value(computeCapacityStatus.ActiveUserSessions)),
+		"actual_user_sessions":    int(aws.Int64Value(computeCapacityStatus.ActualUserSessions)),
+		"available":               int(aws.Int64Value(computeCapacityStatus.Available)),
+		"available_user_sessions": int(aws.Int64Value(computeCapacityStatus.AvailableUserSessions)),
+		"desired":                 int(aws.Int64Value(computeCapacityStatus.Desired)),
+		"desired_user_sessions":   int(aws.Int64Value(computeCapacityStatus.DesiredUserSessions)),
+		"in_use":                  int(aws.Int64Value(computeCapacityStatus.InUse)),
+		"running":                 int(aws.Int64Value(computeCapacityStatus.Running)),
+	}}
+}
+
+func flattenFleetErrors(fleetErrors []*appstream.FleetError) []interface{} {
+	result := make([]interface{}, 0, len(fleetErrors))
+	for _, v := range fleetErrors {
+		result = append(result, map[string]interface{}{
+			"error_code":    aws.StringValue(v.ErrorCode),
+			"error_message": aws.StringValue(v.ErrorMessage),
+		})
+	}
+	return result
+}
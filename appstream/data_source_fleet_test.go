@@ -0,0 +1,124 @@
+package appstream
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/appstream"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+const (
+	testFleetImageCurrent  = "arn:aws:appstream:eu-west-1:123456789012:image/desktop-2026-10"
+	testFleetImageOutdated = "arn:aws:appstream:eu-west-1:123456789012:image/desktop-2026-09"
+)
+
+func testAppstreamFleetsServer(t *testing.T) (*testAppstreamServer, *AWSClient) {
+	t.Helper()
+
+	server := newTestAppstreamServer(t)
+	for _, f := range []struct {
+		name, fleetType, image, state string
+	}{
+		{"desktop-prod", appstream.FleetTypeAlwaysOn, testFleetImageOutdated, appstream.FleetStateRunning},
+		{"desktop-test", appstream.FleetTypeOnDemand, testFleetImageCurrent, appstream.FleetStateRunning},
+		{"kiosk-prod", appstream.FleetTypeOnDemand, testFleetImageOutdated, appstream.FleetStateStopped},
+		{"kiosk-test", appstream.FleetTypeOnDemand, testFleetImageOutdated, appstream.FleetStateRunning},
+	} {
+		server.addFleet(&appstream.Fleet{
+			Name:      aws.String(f.name),
+			FleetType: aws.String(f.fleetType),
+			ImageArn:  aws.String(f.image),
+			State:     aws.String(f.state),
+			ComputeCapacityStatus: &appstream.ComputeCapacityStatus{
+				Available: aws.Int64(1),
+				Desired:   aws.Int64(2),
+				InUse:     aws.Int64(0),
+				Running:   aws.Int64(1),
+			},
+		})
+	}
+	server.fleets["desktop-prod"].FleetErrors = []*appstream.FleetError{
+		{ErrorCode: aws.String(appstream.FleetErrorCodeSubnetHasInsufficientIpAddresses), ErrorMessage: aws.String("subnet-7a5f4b51 is full")},
+	}
+	server.tags["arn:aws:appstream:eu-west-1:123456789012:fleet/desktop-prod"]["env"] = aws.String("prod")
+	server.tags["arn:aws:appstream:eu-west-1:123456789012:fleet/kiosk-prod"]["env"] = aws.String("prod")
+	server.associations["desktop"] = []string{"desktop-prod", "desktop-test"}
+	server.associations["desktop-prod"] = []string{"desktop-prod"}
+	server.pageSize = 3
+
+	return server, server.client(t)
+}
+
+func TestDataSourceAppstreamFleetRead(t *testing.T) {
+	_, client := testAppstreamFleetsServer(t)
+
+	for name, raw := range map[string]map[string]interface{}{
+		"by name": {"name": "desktop-prod"},
+		"by arn":  {"arn": "arn:aws:appstream:eu-west-1:123456789012:fleet/desktop-prod"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			d := schema.TestResourceDataRaw(t, dataSourceAppstreamFleet().Schema, raw)
+			if err := dataSourceAppstreamFleetRead(d, client); err != nil {
+				t.Fatalf("error reading: %s", err)
+			}
+
+			if d.Id() != "desktop-prod" || d.Get("state") != appstream.FleetStateRunning || d.Get("image_arn") != testFleetImageOutdated {
+				t.Errorf("unexpected fleet %s: %q on %q", d.Id(), d.Get("state"), d.Get("image_arn"))
+			}
+			if d.Get("compute_capacity_status.0.desired") != 2 || d.Get("compute_capacity_status.0.available") != 1 {
+				t.Errorf("unexpected compute capacity status %v", d.Get("compute_capacity_status"))
+			}
+			if d.Get("fleet_errors.#") != 1 || d.Get("fleet_errors.0.error_code") != appstream.FleetErrorCodeSubnetHasInsufficientIpAddresses {
+				t.Errorf("unexpected fleet errors %v", d.Get("fleet_errors"))
+			}
+			if got := d.Get("stack_names").([]interface{}); !reflect.DeepEqual(got, []interface{}{"desktop", "desktop-prod"}) {
+				t.Errorf("unexpected stack names %v", got)
+			}
+			if got := d.Get("tags").(map[string]interface{}); !reflect.DeepEqual(got, map[string]interface{}{"env": "prod"}) {
+				t.Errorf("unexpected tags %v", got)
+			}
+		})
+	}
+
+	d := schema.TestResourceDataRaw(t, dataSourceAppstreamFleet().Schema, map[string]interface{}{"arn": "arn:aws:appstream:eu-west-1:123456789012:stack/desktop-prod"})
+	if err := dataSourceAppstreamFleetRead(d, client); err == nil {
+		t.Error("expected an error for a stack ARN")
+	}
+}
+
+func TestDataSourceAppstreamFleetsRead(t *testing.T) {
+	_, client := testAppstreamFleetsServer(t)
+
+	cases := map[string]struct {
+		raw   map[string]interface{}
+		names []interface{}
+	}{
+		"all":        {map[string]interface{}{}, []interface{}{"desktop-prod", "desktop-test", "kiosk-prod", "kiosk-test"}},
+		"state":      {map[string]interface{}{"state": appstream.FleetStateStopped}, []interface{}{"kiosk-prod"}},
+		"fleet type": {map[string]interface{}{"fleet_type": appstream.FleetTypeAlwaysOn}, []interface{}{"desktop-prod"}},
+		"image":      {map[string]interface{}{"image_arn": testFleetImageCurrent}, []interface{}{"desktop-test"}},
+		"tags":       {map[string]interface{}{"tags": map[string]interface{}{"env": "prod"}}, []interface{}{"desktop-prod", "kiosk-prod"}},
+		"running on an image": {
+			map[string]interface{}{"state": appstream.FleetStateRunning, "image_arn": testFleetImageOutdated},
+			[]interface{}{"desktop-prod", "kiosk-test"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			d := schema.TestResourceDataRaw(t, dataSourceAppstreamFleets().Schema, tc.raw)
+			if err := dataSourceAppstreamFleetsRead(d, client); err != nil {
+				t.Fatalf("error reading: %s", err)
+			}
+
+			if got := d.Get("names").([]interface{}); !reflect.DeepEqual(got, tc.names) {
+				t.Errorf("expected names %v, got %v", tc.names, got)
+			}
+			if got := len(d.Get("image_arns").([]interface{})); got != len(tc.names) {
+				t.Errorf("expected %d image ARNs, got %d", len(tc.names), got)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package appstream
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/appstream"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/hashcode"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+// dataSourceAppstreamFleets lists the fleets of the region, optionally filtered. names,
+// arns, image_arns and states are sorted by name and share their order, so fleets on an
+// outdated image can be picked out of the result.
+func dataSourceAppstreamFleets() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceAppstreamFleetsRead,
+
+		Schema: map[string]*schema.Schema{
+			"fleet_type": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice(appstream.FleetType_Values(), false),
+			},
+
+			"image_arn": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateAppstreamImageArn,
+			},
+
+			"name_regex": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringIsValidRegExp,
+			},
+
+			"state": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice(appstream.FleetState_Values(), false),
+			},
+
+			"tags": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+
+			"arns": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+
+			"image_arns": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+
+			"names": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+
+			"states": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func dataSourceAppstreamFleetsRead(d *schema.ResourceData, meta interface{}) error {
+	svc := meta.(*AWSClient).appstreamconn
+
+	fleetType := d.Get("fleet_type").(string)
+	imageArn := d.Get("image_arn").(string)
+	nameRegex := d.Get("name_regex").(string)
+	re := regexp.MustCompile(nameRegex)
+	state := d.Get("state").(string)
+	filterTags := d.Get("tags").(map[string]interface{})
+
+	fleets, err := listFleets(svc)
+	if err != nil {
+		return err
+	}
+
+	matches := make([]*appstream.Fleet, 0, len(fleets))
+	for _, v := range fleets {
+		if fleetType != "" && aws.StringValue(v.FleetType) != fleetType {
+			continue
+		}
+		if imageArn != "" && aws.StringValue(v.ImageArn) != imageArn {
+			continue
+		}
+		if state != "" && aws.StringValue(v.State) != state {
+			continue
+		}
+		if !re.MatchString(aws.StringValue(v.Name)) {
+			continue
+		}
+		if len(filterTags) > 0 {
+			tg, err := svc.ListTagsForResource(&appstream.ListTagsForResourceInput{
+				ResourceArn: v.Arn,
+			})
+			if err != nil {
+				log.Printf("[ERROR] Error listing fleet tags: %s", err)
+				return err
+			}
+			if !tagsMatch(tg.Tags, filterTags) {
+				continue
+			}
+		}
+		matches = append(matches, v)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return aws.StringValue(matches[i].Name) < aws.StringValue(matches[j].Name)
+	})
+
+	names := make([]interface{}, 0, len(matches))
+	arns := make([]interface{}, 0, len(matches))
+	imageArns := make([]interface{}, 0, len(matches))
+	states := make([]interface{}, 0, len(matches))
+	for _, v := range matches {
+		names = append(names, aws.StringValue(v.Name))
+		arns = append(arns, aws.StringValue(v.Arn))
+		imageArns = append(imageArns, aws.StringValue(v.ImageArn))
+		states = append(states, aws.StringValue(v.State))
+	}
+	log.Printf("[DEBUG] %d Appstream Fleets match the filters", len(matches))
+
+	if err := d.Set("names", names); err != nil {
+		log.Printf("[ERROR] Error setting fleet names: %s", err)
+		return err
+	}
+	if err := d.Set("arns", arns); err != nil {
+		log.Printf("[ERROR] Error setting fleet ARNs: %s", err)
+		return err
+	}
+	if err := d.Set("image_arns", imageArns); err != nil {
+		log.Printf("[ERROR] Error setting fleet image ARNs: %s", err)
+		return err
+	}
+	if err := d.Set("states", states); err != nil {
+		log.Printf("[ERROR] Error setting fleet states: %s", err)
+		return err
+	}
+
+	d.SetId(fmt.Sprintf("%d", hashcode.String(fmt.Sprintf("%s/%s/%s/%s/%s/%v",
+		meta.(*AWSClient).region, fleetType, imageArn, nameRegex, state, filterTags))))
+	return nil
+}
+
+// listFleets returns every fleet of the region.
+func listFleets(svc *appstream.AppStream) ([]*appstream.Fleet, error) {
+	fleets := make([]*appstream.Fleet, 0)
+	input := &appstream.DescribeFleetsInput{}
+	for {
+		resp, err := svc.DescribeFleets(input)
+		if err != nil {
+			log.Printf("[ERROR] Error describing Appstream Fleets: %s", err)
+			return nil, err
+		}
+		fleets = append(fleets, resp.Fleets...)
+		if aws.StringValue(resp.NextToken) == "" {
+			return fleets, nil
+		}
+		input.NextToken = resp.NextToken
+	}
+}
@@ -0,0 +1,178 @@
+package appstream
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/aws/aws-sdk-go/service/appstream"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/hashcode"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+// dataSourceAppstreamInstanceTypes filters the embedded instance type catalog, it does not call AWS.
+func dataSourceAppstreamInstanceTypes() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceAppstreamInstanceTypesRead,
+
+		Schema: map[string]*schema.Schema{
+			"family": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			"fleet_type": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice(appstream.FleetType_Values(), false),
+			},
+
+			"gpu": {
+				Type:     schema.TypeBool,
+				Optional: true,
+			},
+
+			"include_deprecated": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+
+			"min_memory_gib": {
+				Type:         schema.TypeFloat,
+				Optional:     true,
+				ValidateFunc: validation.FloatAtLeast(0),
+			},
+
+			"min_vcpu": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+
+			"platform": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice(appstream.PlatformType_Values(), false),
+			},
+
+			"instance_types": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"deprecated": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"family": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"fleet_types": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+						"gpu": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"gpu_memory_gib": {
+							Type:     schema.TypeFloat,
+							Computed: true,
+						},
+						"memory_gib": {
+							Type:     schema.TypeFloat,
+							Computed: true,
+						},
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"platforms": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+						"vcpu": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+					},
+				},
+			},
+
+			"names": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func dataSourceAppstreamInstanceTypesRead(d *schema.ResourceData, meta interface{}) error {
+	family := d.Get("family").(string)
+	fleetType := d.Get("fleet_type").(string)
+	platform := d.Get("platform").(string)
+	includeDeprecated := d.Get("include_deprecated").(bool)
+	minMemory := d.Get("min_memory_gib").(float64)
+	minVCPU := d.Get("min_vcpu").(int)
+	gpu, filterGPU := d.GetOkExists("gpu")
+
+	matches := make([]appstreamInstanceType, 0)
+	for _, t := range appstreamInstanceTypes {
+		if family != "" && t.Family != family {
+			continue
+		}
+		if fleetType != "" && !t.supportsFleetType(fleetType) {
+			continue
+		}
+		if platform != "" && !t.supportsPlatform(platform) {
+			continue
+		}
+		if filterGPU && t.GPU != gpu.(bool) {
+			continue
+		}
+		if t.Deprecated && !includeDeprecated {
+			continue
+		}
+		if t.VCPU < minVCPU || t.MemoryGiB < minMemory {
+			continue
+		}
+		matches = append(matches, t)
+	}
+	sortAppstreamInstanceTypes(matches)
+
+	names := make([]interface{}, 0, len(matches))
+	instanceTypes := make([]interface{}, 0, len(matches))
+	for _, t := range matches {
+		names = append(names, t.Name)
+		instanceTypes = append(instanceTypes, map[string]interface{}{
+			"deprecated":     t.Deprecated,
+			"family":         t.Family,
+			"fleet_types":    t.FleetTypes,
+			"gpu":            t.GPU,
+			"gpu_memory_gib": t.GPUMemoryGiB,
+			"memory_gib":     t.MemoryGiB,
+			"name":           t.Name,
+			"platforms":      t.Platforms,
+			"vcpu":           t.VCPU,
+		})
+	}
+	log.Printf("[DEBUG] %d Appstream instance types match the filters", len(matches))
+
+	if err := d.Set("names", names); err != nil {
+		log.Printf("[ERROR] Error setting instance type names: %s", err)
+		return err
+	}
+	if err := d.Set("instance_types", instanceTypes); err != nil {
+		log.Printf("[ERROR] Error setting instance types: %s", err)
+		return err
+	}
+
+	d.SetId(fmt.Sprintf("%d", hashcode.String(fmt.Sprintf("%s/%s/%s/%t/%t/%t/%g/%d",
+		family, fleetType, platform, filterGPU, gpu, includeDeprecated, minMemory, minVCPU))))
+	return nil
+}
@@ -0,0 +1,281 @@
+package appstream
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/appstream"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+// dataSourceAppstreamStack looks up a stack by name or ARN, typically one managed in another
+// configuration. Unlike the resource it reports every user setting and connector as returned.
+func dataSourceAppstreamStack() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceAppstreamStackRead,
+
+		Schema: map[string]*schema.Schema{
+			"access_endpoints": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"endpoint_type": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"vpce_id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+
+			"application_settings": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"enabled": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"s3_bucket_name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"settings_group": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+
+			"arn": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ExactlyOneOf: []string{"arn", "name"},
+			},
+
+			"created_time": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"deletion_protection": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+
+			"description": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"display_name": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"embed_host_domains": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+
+			"feedback_url": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"fleet_names": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+
+			"name": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validateAppstreamName,
+			},
+
+			"redirect_url": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"storage_connectors": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"connector_type": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"domains": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+						"resource_identifier": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+
+			"streaming_experience_settings": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"preferred_protocol": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+
+			"tags": {
+				Type:     schema.TypeMap,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+
+			"user_settings": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"action": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"enabled": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"maximum_length": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceAppstreamStackRead(d *schema.ResourceData, meta interface{}) error {
+	svc := meta.(*AWSClient).appstreamconn
+
+	name := d.Get("name").(string)
+	if v, ok := d.GetOk("arn"); ok {
+		n, err := appstreamNameFromArn(v.(string), "stack")
+		if err != nil {
+			return err
+		}
+		name = n
+	}
+
+	v, err := describeStack(svc, name)
+	if err != nil {
+		return err
+	}
+	if v == nil || (d.Get("arn").(string) != "" && aws.StringValue(v.Arn) != d.Get("arn").(string)) {
+		return fmt.Errorf("Appstream Stack %s not found", name)
+	}
+
+	d.SetId(aws.StringValue(v.Name))
+	d.Set("arn", v.Arn)
+	d.Set("name", v.Name)
+	d.Set("description", v.Description)
+	d.Set("display_name", v.DisplayName)
+	d.Set("feedback_url", v.FeedbackURL)
+	d.Set("redirect_url", v.RedirectURL)
+	if v.CreatedTime != nil {
+		d.Set("created_time", aws.TimeValue(v.CreatedTime).Format(time.RFC3339))
+	}
+
+	if err := d.Set("access_endpoints", flattenAccessEndpoints(v.AccessEndpoints)); err != nil {
+		log.Printf("[ERROR] Error setting access endpoints: %s", err)
+		return err
+	}
+
+	if err := d.Set("application_settings", flattenApplicationSettings(v.ApplicationSettings)); err != nil {
+		log.Printf("[ERROR] Error setting application settings: %s", err)
+		return err
+	}
+
+	if err := d.Set("embed_host_domains", flattenStringList(v.EmbedHostDomains)); err != nil {
+		log.Printf("[ERROR] Error setting embed host domains: %s", err)
+		return err
+	}
+
+	storageConnectors := make([]interface{}, 0, len(v.StorageConnectors))
+	for _, sc := range v.StorageConnectors {
+		storageConnectors = append(storageConnectors, map[string]interface{}{
+			"connector_type":      aws.StringValue(sc.ConnectorType),
+			"domains":             flattenStringList(sc.Domains),
+			"resource_identifier": aws.StringValue(sc.ResourceIdentifier),
+		})
+	}
+	if err := d.Set("storage_connectors", storageConnectors); err != nil {
+		log.Printf("[ERROR] Error setting storage connectors: %s", err)
+		return err
+	}
+
+	if err := d.Set("streaming_experience_settings", flattenStreamingExperienceSettings(v.StreamingExperienceSettings)); err != nil {
+		log.Printf("[ERROR] Error setting streaming experience settings: %s", err)
+		return err
+	}
+
+	userSettings := make([]interface{}, 0, len(v.UserSettings))
+	for _, us := range v.UserSettings {
+		userSettings = append(userSettings, map[string]interface{}{
+			"action":         aws.StringValue(us.Action),
+			"enabled":        aws.StringValue(us.Permission) == appstream.PermissionEnabled,
+			"maximum_length": int(aws.Int64Value(us.MaximumLength)),
+		})
+	}
+	if err := d.Set("user_settings", userSettings); err != nil {
+		log.Printf("[ERROR] Error setting user settings: %s", err)
+		return err
+	}
+
+	fleets, err := listAssociatedFleets(svc, aws.StringValue(v.Name))
+	if err != nil {
+		return err
+	}
+	if err := d.Set("fleet_names", fleets); err != nil {
+		log.Printf("[ERROR] Error setting fleet names: %s", err)
+		return err
+	}
+
+	tg, err := svc.ListTagsForResource(&appstream.ListTagsForResourceInput{
+		ResourceArn: v.Arn,
+	})
+	if err != nil {
+		log.Printf("[ERROR] Error listing stack tags: %s", err)
+		return err
+	}
+
+	d.Set("deletion_protection", aws.StringValue(tg.Tags[deletionProtectionTagKey]) == "true")
+
+	if err := d.Set("tags", New(tg.Tags).IgnoreAWS().IgnoreProvider().Map()); err != nil {
+		log.Printf("[ERROR] Error setting stack tags: %s", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package appstream
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/appstream"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func testAppstreamStacksServer(t *testing.T) (*testAppstreamServer, *AWSClient) {
+	t.Helper()
+
+	server := newTestAppstreamServer(t)
+	for _, name := range []string{"desktop-prod", "desktop-test", "kiosk-prod"} {
+		server.addStack(&appstream.Stack{
+			Name:        aws.String(name),
+			Description: aws.String(name + " stack"),
+			StorageConnectors: []*appstream.StorageConnector{
+				{ConnectorType: aws.String(appstream.StorageConnectorTypeHomefolders), ResourceIdentifier: aws.String("appstream2-36fb080bb8-eu-west-1-123456789012")},
+			},
+			UserSettings: []*appstream.UserSetting{
+				{Action: aws.String(appstream.ActionFileDownload), Permission: aws.String(appstream.PermissionDisabled)},
+				{Action: aws.String(appstream.ActionFileUpload), Permission: aws.String(appstream.PermissionEnabled)},
+			},
+		})
+	}
+	server.tags["arn:aws:appstream:eu-west-1:123456789012:stack/desktop-prod"]["env"] = aws.String("prod")
+	server.tags["arn:aws:appstream:eu-west-1:123456789012:stack/desktop-prod"][deletionProtectionTagKey] = aws.String("true")
+	server.tags["arn:aws:appstream:eu-west-1:123456789012:stack/kiosk-prod"]["env"] = aws.String("prod")
+	server.associations["desktop-prod"] = []string{"desktop-prod-fleet"}
+	server.pageSize = 1
+
+	return server, server.client(t)
+}
+
+func TestDataSourceAppstreamStackRead(t *testing.T) {
+	_, client := testAppstreamStacksServer(t)
+
+	for name, raw := range map[string]map[string]interface{}{
+		"by name": {"name": "desktop-prod"},
+		"by arn":  {"arn": "arn:aws:appstream:eu-west-1:123456789012:stack/desktop-prod"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			d := schema.TestResourceDataRaw(t, dataSourceAppstreamStack().Schema, raw)
+			if err := dataSourceAppstreamStackRead(d, client); err != nil {
+				t.Fatalf("error reading: %s", err)
+			}
+
+			if d.Id() != "desktop-prod" || d.Get("description") != "desktop-prod stack" {
+				t.Errorf("unexpected stack %s: %q", d.Id(), d.Get("description"))
+			}
+			if got := d.Get("fleet_names").([]interface{}); !reflect.DeepEqual(got, []interface{}{"desktop-prod-fleet"}) {
+				t.Errorf("unexpected fleet names %v", got)
+			}
+			if got := d.Get("tags").(map[string]interface{}); !reflect.DeepEqual(got, map[string]interface{}{"env": "prod"}) {
+				t.Errorf("unexpected tags %v", got)
+			}
+			if !d.Get("deletion_protection").(bool) {
+				t.Error("expected deletion_protection")
+			}
+			if got := d.Get("user_settings.#").(int); got != 2 {
+				t.Errorf("expected every user setting, got %d", got)
+			}
+			if got := d.Get("storage_connectors.0.resource_identifier"); got != "appstream2-36fb080bb8-eu-west-1-123456789012" {
+				t.Errorf("unexpected resource identifier %q", got)
+			}
+		})
+	}
+
+	d := schema.TestResourceDataRaw(t, dataSourceAppstreamStack().Schema, map[string]interface{}{"name": "missing"})
+	if err := dataSourceAppstreamStackRead(d, client); err == nil {
+		t.Error("expected an error for a missing stack")
+	}
+}
+
+func TestDataSourceAppstreamStacksRead(t *testing.T) {
+	server, client := testAppstreamStacksServer(t)
+
+	cases := map[string]struct {
+		raw   map[string]interface{}
+		names []interface{}
+	}{
+		"all":        {map[string]interface{}{}, []interface{}{"desktop-prod", "desktop-test", "kiosk-prod"}},
+		"name regex": {map[string]interface{}{"name_regex": "^desktop-"}, []interface{}{"desktop-prod", "desktop-test"}},
+		"tags":       {map[string]interface{}{"tags": map[string]interface{}{"env": "prod"}}, []interface{}{"desktop-prod", "kiosk-prod"}},
+		"both":       {map[string]interface{}{"name_regex": "^kiosk-", "tags": map[string]interface{}{"env": "prod"}}, []interface{}{"kiosk-prod"}},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			d := schema.TestResourceDataRaw(t, dataSourceAppstreamStacks().Schema, tc.raw)
+			if err := dataSourceAppstreamStacksRead(d, client); err != nil {
+				t.Fatalf("error reading: %s", err)
+			}
+
+			if got := d.Get("names").([]interface{}); !reflect.DeepEqual(got, tc.names) {
+				t.Errorf("expected names %v, got %v", tc.names, got)
+			}
+			arns := d.Get("arns").([]interface{})
+			for i, n := range tc.names {
+				if arns[i] != "arn:aws:appstream:eu-west-1:123456789012:stack/"+n.(string) {
+					t.Errorf("unexpected ARN %v for %s", arns[i], n)
+				}
+			}
+		})
+	}
+
+	// Three stacks with one per page.
+	if got := server.calls["DescribeStacks"]; got != 3*len(cases) {
+		t.Errorf("expected %d DescribeStacks calls, got %d", 3*len(cases), got)
+	}
+}
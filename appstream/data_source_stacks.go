@@ -0,0 +1,123 @@
+package appstream
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/appstream"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/hashcode"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+// dataSourceAppstreamStacks lists the stacks of the region, optionally filtered by name and
+// tags. names and arns are sorted by name and share their order.
+func dataSourceAppstreamStacks() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceAppstreamStacksRead,
+
+		Schema: map[string]*schema.Schema{
+			"name_regex": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringIsValidRegExp,
+			},
+
+			"tags": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+
+			"arns": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+
+			"names": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func dataSourceAppstreamStacksRead(d *schema.ResourceData, meta interface{}) error {
+	svc := meta.(*AWSClient).appstreamconn
+
+	nameRegex := d.Get("name_regex").(string)
+	re := regexp.MustCompile(nameRegex)
+	filterTags := d.Get("tags").(map[string]interface{})
+
+	stacks, err := listStacks(svc)
+	if err != nil {
+		return err
+	}
+
+	matches := make([]*appstream.Stack, 0, len(stacks))
+	for _, v := range stacks {
+		if !re.MatchString(aws.StringValue(v.Name)) {
+			continue
+		}
+		if len(filterTags) > 0 {
+			tg, err := svc.ListTagsForResource(&appstream.ListTagsForResourceInput{
+				ResourceArn: v.Arn,
+			})
+			if err != nil {
+				log.Printf("[ERROR] Error listing stack tags: %s", err)
+				return err
+			}
+			if !tagsMatch(tg.Tags, filterTags) {
+				continue
+			}
+		}
+		matches = append(matches, v)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return aws.StringValue(matches[i].Name) < aws.StringValue(matches[j].Name)
+	})
+
+	names := make([]interface{}, 0, len(matches))
+	arns := make([]interface{}, 0, len(matches))
+	for _, v := range matches {
+		names = append(names, aws.StringValue(v.Name))
+		arns = append(arns, aws.StringValue(v.Arn))
+	}
+	log.Printf("[DEBUG] %d Appstream Stacks match the filters", len(matches))
+
+	if err := d.Set("names", names); err != nil {
+		log.Printf("[ERROR] Error setting stack names: %s", err)
+		return err
+	}
+	if err := d.Set("arns", arns); err != nil {
+		log.Printf("[ERROR] Error setting stack ARNs: %s", err)
+		return err
+	}
+
+	d.SetId(fmt.Sprintf("%d", hashcode.String(fmt.Sprintf("%s/%s/%v",
+		meta.(*AWSClient).region, nameRegex, filterTags))))
+	return nil
+}
+
+// listStacks returns every stack of the region.
+func listStacks(svc *appstream.AppStream) ([]*appstream.Stack, error) {
+	stacks := make([]*appstream.Stack, 0)
+	input := &appstream.DescribeStacksInput{}
+	for {
+		resp, err := svc.DescribeStacks(input)
+		if err != nil {
+			log.Printf("[ERROR] Error describing Appstream Stacks: %s", err)
+			return nil, err
+		}
+		stacks = append(stacks, resp.Stacks...)
+		if aws.StringValue(resp.NextToken) == "" {
+			return stacks, nil
+		}
+		input.NextToken = resp.NextToken
+	}
+}
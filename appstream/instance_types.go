@@ -0,0 +1,190 @@
+package appstream
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/appstream"
+)
+
+// appstreamInstanceType describes one entry of the AppStream 2.0 instance type catalog.
+type appstreamInstanceType struct {
+	Name         string
+	Family       string
+	VCPU         int
+	MemoryGiB    float64
+	GPU          bool
+	GPUMemoryGiB float64
+	Platforms    []string
+	FleetTypes   []string
+	Deprecated   bool
+}
+
+var (
+	windowsPlatforms = []string{
+		appstream.PlatformTypeWindows,
+		appstream.PlatformTypeWindowsServer2016,
+		appstream.PlatformTypeWindowsServer2019,
+		appstream.PlatformTypeWindowsServer2022,
+	}
+	allPlatforms = append(append([]string{}, windowsPlatforms...),
+		appstream.PlatformTypeAmazonLinux2,
+		appstream.PlatformTypeRhel8,
+	)
+	linuxGpuPlatforms = append(append([]string{}, windowsPlatforms...),
+		appstream.PlatformTypeAmazonLinux2,
+	)
+
+	allFleetTypes = []string{
+		appstream.FleetTypeAlwaysOn,
+		appstream.FleetTypeOnDemand,
+		appstream.FleetTypeElastic,
+	}
+	provisionedFleetTypes = []string{
+		appstream.FleetTypeAlwaysOn,
+		appstream.FleetTypeOnDemand,
+	}
+)
+
+// appstreamInstanceTypes is the catalog of stream.* instance types, ordered by family and size.
+// Keep it in sync with https://aws.amazon.com/appstream2/pricing/ when AWS adds or retires types.
+var appstreamInstanceTypes = []appstreamInstanceType{
+	{Name: "stream.standard.small", Family: "stream.standard", VCPU: 2, MemoryGiB: 2, Platforms: allPlatforms, FleetTypes: allFleetTypes},
+	{Name: "stream.standard.medium", Family: "stream.standard", VCPU: 2, MemoryGiB: 4, Platforms: allPlatforms, FleetTypes: allFleetTypes},
+	{Name: "stream.standard.large", Family: "stream.standard", VCPU: 2, MemoryGiB: 8, Platforms: allPlatforms, FleetTypes: allFleetTypes},
+	{Name: "stream.standard.xlarge", Family: "stream.standard", VCPU: 4, MemoryGiB: 16, Platforms: allPlatforms, FleetTypes: allFleetTypes},
+	{Name: "stream.standard.2xlarge", Family: "stream.standard", VCPU: 8, MemoryGiB: 32, Platforms: allPlatforms, FleetTypes: allFleetTypes},
+
+	{Name: "stream.compute.large", Family: "stream.compute", VCPU: 2, MemoryGiB: 3.75, Platforms: allPlatforms, FleetTypes: provisionedFleetTypes},
+	{Name: "stream.compute.xlarge", Family: "stream.compute", VCPU: 4, MemoryGiB: 7.5, Platforms: allPlatforms, FleetTypes: provisionedFleetTypes},
+	{Name: "stream.compute.2xlarge", Family: "stream.compute", VCPU: 8, MemoryGiB: 15, Platforms: allPlatforms, FleetTypes: provisionedFleetTypes},
+	{Name: "stream.compute.4xlarge", Family: "stream.compute", VCPU: 16, MemoryGiB: 30, Platforms: allPlatforms, FleetTypes: provisionedFleetTypes},
+	{Name: "stream.compute.8xlarge", Family: "stream.compute", VCPU: 36, MemoryGiB: 60, Platforms: allPlatforms, FleetTypes: provisionedFleetTypes},
+
+	{Name: "stream.memory.large", Family: "stream.memory", VCPU: 2, MemoryGiB: 15.25, Platforms: allPlatforms, FleetTypes: provisionedFleetTypes},
+	{Name: "stream.memory.xlarge", Family: "stream.memory", VCPU: 4, MemoryGiB: 30.5, Platforms: allPlatforms, FleetTypes: provisionedFleetTypes},
+	{Name: "stream.memory.2xlarge", Family: "stream.memory", VCPU: 8, MemoryGiB: 61, Platforms: allPlatforms, FleetTypes: provisionedFleetTypes},
+	{Name: "stream.memory.4xlarge", Family: "stream.memory", VCPU: 16, MemoryGiB: 122, Platforms: allPlatforms, FleetTypes: provisionedFleetTypes},
+	{Name: "stream.memory.8xlarge", Family: "stream.memory", VCPU: 32, MemoryGiB: 244, Platforms: allPlatforms, FleetTypes: provisionedFleetTypes},
+
+	{Name: "stream.memory.z1d.large", Family: "stream.memory.z1d", VCPU: 2, MemoryGiB: 16, Platforms: windowsPlatforms, FleetTypes: provisionedFleetTypes},
+	{Name: "stream.memory.z1d.xlarge", Family: "stream.memory.z1d", VCPU: 4, MemoryGiB: 32, Platforms: windowsPlatforms, FleetTypes: provisionedFleetTypes},
+	{Name: "stream.memory.z1d.2xlarge", Family: "stream.memory.z1d", VCPU: 8, MemoryGiB: 64, Platforms: windowsPlatforms, FleetTypes: provisionedFleetTypes},
+	{Name: "stream.memory.z1d.3xlarge", Family: "stream.memory.z1d", VCPU: 12, MemoryGiB: 96, Platforms: windowsPlatforms, FleetTypes: provisionedFleetTypes},
+	{Name: "stream.memory.z1d.6xlarge", Family: "stream.memory.z1d", VCPU: 24, MemoryGiB: 192, Platforms: windowsPlatforms, FleetTypes: provisionedFleetTypes},
+	{Name: "stream.memory.z1d.12xlarge", Family: "stream.memory.z1d", VCPU: 48, MemoryGiB: 384, Platforms: windowsPlatforms, FleetTypes: provisionedFleetTypes},
+
+	{Name: "stream.graphics.g4dn.xlarge", Family: "stream.graphics.g4dn", VCPU: 4, MemoryGiB: 16, GPU: true, GPUMemoryGiB: 16, Platforms: linuxGpuPlatforms, FleetTypes: provisionedFleetTypes},
+	{Name: "stream.graphics.g4dn.2xlarge", Family: "stream.graphics.g4dn", VCPU: 8, MemoryGiB: 32, GPU: true, GPUMemoryGiB: 16, Platforms: linuxGpuPlatforms, FleetTypes: provisionedFleetTypes},
+	{Name: "stream.graphics.g4dn.4xlarge", Family: "stream.graphics.g4dn", VCPU: 16, MemoryGiB: 64, GPU: true, GPUMemoryGiB: 16, Platforms: linuxGpuPlatforms, FleetTypes: provisionedFleetTypes},
+	{Name: "stream.graphics.g4dn.8xlarge", Family: "stream.graphics.g4dn", VCPU: 32, MemoryGiB: 128, GPU: true, GPUMemoryGiB: 16, Platforms: linuxGpuPlatforms, FleetTypes: provisionedFleetTypes},
+	{Name: "stream.graphics.g4dn.12xlarge", Family: "stream.graphics.g4dn", VCPU: 48, MemoryGiB: 192, GPU: true, GPUMemoryGiB: 64, Platforms: linuxGpuPlatforms, FleetTypes: provisionedFleetTypes},
+	{Name: "stream.graphics.g4dn.16xlarge", Family: "stream.graphics.g4dn", VCPU: 64, MemoryGiB: 256, GPU: true, GPUMemoryGiB: 16, Platforms: linuxGpuPlatforms, FleetTypes: provisionedFleetTypes},
+
+	{Name: "stream.graphics.g5.xlarge", Family: "stream.graphics.g5", VCPU: 4, MemoryGiB: 16, GPU: true, GPUMemoryGiB: 24, Platforms: windowsPlatforms, FleetTypes: provisionedFleetTypes},
+	{Name: "stream.graphics.g5.2xlarge", Family: "stream.graphics.g5", VCPU: 8, MemoryGiB: 32, GPU: true, GPUMemoryGiB: 24, Platforms: windowsPlatforms, FleetTypes: provisionedFleetTypes},
+	{Name: "stream.graphics.g5.4xlarge", Family: "stream.graphics.g5", VCPU: 16, MemoryGiB: 64, GPU: true, GPUMemoryGiB: 24, Platforms: windowsPlatforms, FleetTypes: provisionedFleetTypes},
+	{Name: "stream.graphics.g5.8xlarge", Family: "stream.graphics.g5", VCPU: 32, MemoryGiB: 128, GPU: true, GPUMemoryGiB: 24, Platforms: windowsPlatforms, FleetTypes: provisionedFleetTypes},
+	{Name: "stream.graphics.g5.12xlarge", Family: "stream.graphics.g5", VCPU: 48, MemoryGiB: 192, GPU: true, GPUMemoryGiB: 96, Platforms: windowsPlatforms, FleetTypes: provisionedFleetTypes},
+	{Name: "stream.graphics.g5.16xlarge", Family: "stream.graphics.g5", VCPU: 64, MemoryGiB: 256, GPU: true, GPUMemoryGiB: 24, Platforms: windowsPlatforms, FleetTypes: provisionedFleetTypes},
+	{Name: "stream.graphics.g5.24xlarge", Family: "stream.graphics.g5", VCPU: 96, MemoryGiB: 384, GPU: true, GPUMemoryGiB: 96, Platforms: windowsPlatforms, FleetTypes: provisionedFleetTypes},
+
+	{Name: "stream.graphics.g6.xlarge", Family: "stream.graphics.g6", VCPU: 4, MemoryGiB: 16, GPU: true, GPUMemoryGiB: 24, Platforms: windowsPlatforms, FleetTypes: provisionedFleetTypes},
+	{Name: "stream.graphics.g6.2xlarge", Family: "stream.graphics.g6", VCPU: 8, MemoryGiB: 32, GPU: true, GPUMemoryGiB: 24, Platforms: windowsPlatforms, FleetTypes: provisionedFleetTypes},
+	{Name: "stream.graphics.g6.4xlarge", Family: "stream.graphics.g6", VCPU: 16, MemoryGiB: 64, GPU: true, GPUMemoryGiB: 24, Platforms: windowsPlatforms, FleetTypes: provisionedFleetTypes},
+	{Name: "stream.graphics.g6.8xlarge", Family: "stream.graphics.g6", VCPU: 32, MemoryGiB: 128, GPU: true, GPUMemoryGiB: 24, Platforms: windowsPlatforms, FleetTypes: provisionedFleetTypes},
+	{Name: "stream.graphics.g6.12xlarge", Family: "stream.graphics.g6", VCPU: 48, MemoryGiB: 192, GPU: true, GPUMemoryGiB: 96, Platforms: windowsPlatforms, FleetTypes: provisionedFleetTypes},
+	{Name: "stream.graphics.g6.16xlarge", Family: "stream.graphics.g6", VCPU: 64, MemoryGiB: 256, GPU: true, GPUMemoryGiB: 24, Platforms: windowsPlatforms, FleetTypes: provisionedFleetTypes},
+	{Name: "stream.graphics.g6.24xlarge", Family: "stream.graphics.g6", VCPU: 96, MemoryGiB: 384, GPU: true, GPUMemoryGiB: 96, Platforms: windowsPlatforms, FleetTypes: provisionedFleetTypes},
+
+	{Name: "stream.graphics.gr6.4xlarge", Family: "stream.graphics.gr6", VCPU: 16, MemoryGiB: 128, GPU: true, GPUMemoryGiB: 24, Platforms: windowsPlatforms, FleetTypes: provisionedFleetTypes},
+	{Name: "stream.graphics.gr6.8xlarge", Family: "stream.graphics.gr6", VCPU: 32, MemoryGiB: 256, GPU: true, GPUMemoryGiB: 24, Platforms: windowsPlatforms, FleetTypes: provisionedFleetTypes},
+
+	{Name: "stream.graphics-pro.4xlarge", Family: "stream.graphics-pro", VCPU: 16, MemoryGiB: 122, GPU: true, GPUMemoryGiB: 8, Platforms: windowsPlatforms, FleetTypes: provisionedFleetTypes},
+	{Name: "stream.graphics-pro.8xlarge", Family: "stream.graphics-pro", VCPU: 32, MemoryGiB: 244, GPU: true, GPUMemoryGiB: 16, Platforms: windowsPlatforms, FleetTypes: provisionedFleetTypes},
+	{Name: "stream.graphics-pro.16xlarge", Family: "stream.graphics-pro", VCPU: 64, MemoryGiB: 488, GPU: true, GPUMemoryGiB: 32, Platforms: windowsPlatforms, FleetTypes: provisionedFleetTypes},
+
+	{Name: "stream.graphics-design.large", Family: "stream.graphics-design", VCPU: 2, MemoryGiB: 7.5, GPU: true, GPUMemoryGiB: 1, Platforms: windowsPlatforms, FleetTypes: provisionedFleetTypes, Deprecated: true},
+	{Name: "stream.graphics-design.xlarge", Family: "stream.graphics-design", VCPU: 4, MemoryGiB: 15.3, GPU: true, GPUMemoryGiB: 2, Platforms: windowsPlatforms, FleetTypes: provisionedFleetTypes, Deprecated: true},
+	{Name: "stream.graphics-design.2xlarge", Family: "stream.graphics-design", VCPU: 8, MemoryGiB: 30.5, GPU: true, GPUMemoryGiB: 4, Platforms: windowsPlatforms, FleetTypes: provisionedFleetTypes, Deprecated: true},
+	{Name: "stream.graphics-design.4xlarge", Family: "stream.graphics-design", VCPU: 16, MemoryGiB: 61, GPU: true, GPUMemoryGiB: 8, Platforms: windowsPlatforms, FleetTypes: provisionedFleetTypes, Deprecated: true},
+
+	{Name: "stream.graphics-desktop.2xlarge", Family: "stream.graphics-desktop", VCPU: 8, MemoryGiB: 15, GPU: true, GPUMemoryGiB: 4, Platforms: windowsPlatforms, FleetTypes: provisionedFleetTypes, Deprecated: true},
+}
+
+// findAppstreamInstanceType returns the catalog entry of the named instance type, or nil when it is unknown.
+func findAppstreamInstanceType(name string) *appstreamInstanceType {
+	for i := range appstreamInstanceTypes {
+		if appstreamInstanceTypes[i].Name == name {
+			return &appstreamInstanceTypes[i]
+		}
+	}
+	return nil
+}
+
+// supportsFleetType reports whether fleets of the given type can run this instance type.
+func (t *appstreamInstanceType) supportsFleetType(fleetType string) bool {
+	return stringInSlice(fleetType, t.FleetTypes)
+}
+
+// supportsPlatform reports whether images of the given platform can run on this instance type.
+func (t *appstreamInstanceType) supportsPlatform(platform string) bool {
+	return stringInSlice(platform, t.Platforms)
+}
+
+// sortAppstreamInstanceTypes orders instance types from the smallest to the largest.
+func sortAppstreamInstanceTypes(types []appstreamInstanceType) {
+	sort.SliceStable(types, func(i, j int) bool {
+		if types[i].VCPU != types[j].VCPU {
+			return types[i].VCPU < types[j].VCPU
+		}
+		if types[i].MemoryGiB != types[j].MemoryGiB {
+			return types[i].MemoryGiB < types[j].MemoryGiB
+		}
+		return types[i].Name < types[j].Name
+	})
+}
+
+// validateAppstreamInstanceType rejects names that are not AppStream instance types and warns
+// about deprecated families. A stream.* type missing from the catalog only warns, so types
+// AWS adds after a release can be used before the catalog catches up.
+func validateAppstreamInstanceType(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	if !strings.HasPrefix(value, "stream.") {
+		errors = append(errors, fmt.Errorf("%q must be an AppStream instance type such as stream.standard.medium, got %q", k, value))
+		return
+	}
+	t := findAppstreamInstanceType(value)
+	if t == nil {
+		ws = append(ws, fmt.Sprintf("%q: %s is not in the instance type catalog of this provider, check it is spelled correctly", k, value))
+		return
+	}
+	if t.Deprecated {
+		ws = append(ws, fmt.Sprintf("%q: the %s instance family is deprecated by AWS, consider a current family", k, t.Family))
+	}
+	return
+}
+
+// validateAppstreamFleetInstanceType checks that the instance type can run a fleet of the given type.
+func validateAppstreamFleetInstanceType(instanceType string, fleetType string) error {
+	t := findAppstreamInstanceType(instanceType)
+	if t == nil || fleetType == "" {
+		return nil
+	}
+	if !t.supportsFleetType(fleetType) {
+		return fmt.Errorf("instance type %s does not support %s fleets, supported fleet types: %s",
+			instanceType, fleetType, strings.Join(t.FleetTypes, ", "))
+	}
+	return nil
+}
+
+func stringInSlice(value string, list []string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package appstream
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/appstream"
+)
+
+func TestValidateAppstreamInstanceType(t *testing.T) {
+	cases := map[string]struct {
+		warnings int
+		errors   int
+	}{
+		"stream.standard.medium":          {0, 0},
+		"stream.graphics.g4dn.xlarge":     {0, 0},
+		"stream.graphics.g6.xlarge":       {0, 0},
+		"stream.graphics.gr6.8xlarge":     {0, 0},
+		"stream.graphics-design.large":    {1, 0},
+		"stream.standard.huge":            {1, 0},
+		"m5.large":                        {0, 1},
+		"stream.graphics-desktop.2xlarge": {1, 0},
+	}
+
+	for value, tc := range cases {
+		ws, errs := validateAppstreamInstanceType(value, "instance_type")
+		if len(ws) != tc.warnings || len(errs) != tc.errors {
+			t.Errorf("%s: expected %d warnings and %d errors, got %v and %v", value, tc.warnings, tc.errors, ws, errs)
+		}
+	}
+}
+
+func TestValidateAppstreamFleetInstanceType(t *testing.T) {
+	if err := validateAppstreamFleetInstanceType("stream.graphics.g4dn.xlarge", appstream.FleetTypeElastic); err == nil {
+		t.Error("expected GPU instance types to be rejected on ELASTIC fleets")
+	}
+	if err := validateAppstreamFleetInstanceType("stream.standard.small", appstream.FleetTypeElastic); err != nil {
+		t.Errorf("expected stream.standard.small on ELASTIC fleets to be accepted, got %s", err)
+	}
+	if err := validateAppstreamFleetInstanceType("stream.graphics.g4dn.xlarge", appstream.FleetTypeAlwaysOn); err != nil {
+		t.Errorf("expected GPU instance types on ALWAYS_ON fleets to be accepted, got %s", err)
+	}
+}
+
+func TestSortAppstreamInstanceTypes(t *testing.T) {
+	types := []appstreamInstanceType{
+		*findAppstreamInstanceType("stream.memory.large"),
+		*findAppstreamInstanceType("stream.standard.xlarge"),
+		*findAppstreamInstanceType("stream.compute.large"),
+	}
+	sortAppstreamInstanceTypes(types)
+
+	expected := []string{"stream.compute.large", "stream.memory.large", "stream.standard.xlarge"}
+	for i, name := range expected {
+		if types[i].Name != name {
+			t.Errorf("position %d: expected %s, got %s", i, name, types[i].Name)
+		}
+	}
+}
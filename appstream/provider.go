@@ -1,14 +1,14 @@
 package appstream
 
 import (
-        "github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/terraform"
 	homedir "github.com/mitchellh/go-homedir"
 	"log"
 )
 
 func Provider() terraform.ResourceProvider {
-	
+
 	provider := &schema.Provider{
 		Schema: map[string]*schema.Schema{
 			"access_key": {
@@ -58,12 +58,26 @@ func Provider() terraform.ResourceProvider {
 				Description:  "Region",
 				InputDefault: "us-east-1",
 			},
+
+			"endpoints": endpointsSchema(),
+		},
+
+		DataSourcesMap: map[string]*schema.Resource{
+			"appstream_fleet":          dataSourceAppstreamFleet(),
+			"appstream_fleets":         dataSourceAppstreamFleets(),
+			"appstream_instance_types": dataSourceAppstreamInstanceTypes(),
+			"appstream_stack":          dataSourceAppstreamStack(),
+			"appstream_stacks":         dataSourceAppstreamStacks(),
 		},
 
 		ResourcesMap: map[string]*schema.Resource{
-		    "appstream_stack":  		resourceAppstreamStack(),
-		    "appstream_image_builder":  resourceAppstreamImageBuilder(),
-		    "appstream_fleet":			resourceAppstreamFleet(),
+			"appstream_stack":                  resourceAppstreamStack(),
+			"appstream_image_builder":          resourceAppstreamImageBuilder(),
+			"appstream_fleet":                  resourceAppstreamFleet(),
+			"appstream_fleet_scalable_target":  resourceAppstreamFleetScalableTarget(),
+			"appstream_fleet_scaling_policy":   resourceAppstreamFleetScalingPolicy(),
+			"appstream_fleet_scheduled_action": resourceAppstreamFleetScheduledAction(),
+			"appstream_stack_theme":            resourceAppstreamStackTheme(),
 		},
 	}
 	provider.ConfigureFunc = func(d *schema.ResourceData) (interface{}, error) {
@@ -143,15 +157,24 @@ func init() {
 	}
 }
 func providerConfigure(d *schema.ResourceData, terraformVersion string) (interface{}, error) {
-	config := Config {
-        AccessKey:  d.Get("access_key").(string),
-		SecretKey:  d.Get("secret_key").(string),
-		Profile:    d.Get("profile").(string),
-		Token:      d.Get("token").(string),
-		Region:     d.Get("region").(string),
+	config := Config{
+		AccessKey:        d.Get("access_key").(string),
+		SecretKey:        d.Get("secret_key").(string),
+		Profile:          d.Get("profile").(string),
+		Token:            d.Get("token").(string),
+		Region:           d.Get("region").(string),
 		terraformVersion: terraformVersion,
 	}
 
+	config.Endpoints = make(map[string]string)
+	endpointsSet := d.Get("endpoints").(*schema.Set)
+	for _, endpointsSetI := range endpointsSet.List() {
+		endpoints := endpointsSetI.(map[string]interface{})
+		for _, endpointServiceName := range endpointServiceNames {
+			config.Endpoints[endpointServiceName] = endpoints[endpointServiceName].(string)
+		}
+	}
+
 	// Set CredsFilename, expanding home directory
 	credsPath, err := homedir.Expand(d.Get("shared_credentials_file").(string))
 	if err != nil {
@@ -212,3 +235,33 @@ func assumeRoleSchema() *schema.Schema {
 		},
 	}
 }
+
+var endpointServiceNames = []string{
+	"applicationautoscaling",
+	"appstream",
+	"iam",
+	"imagebuilder",
+	"s3",
+	"sts",
+}
+
+func endpointsSchema() *schema.Schema {
+	endpointsAttributes := make(map[string]*schema.Schema)
+
+	for _, endpointServiceName := range endpointServiceNames {
+		endpointsAttributes[endpointServiceName] = &schema.Schema{
+			Type:        schema.TypeString,
+			Optional:    true,
+			Default:     "",
+			Description: descriptions["endpoint"],
+		}
+	}
+
+	return &schema.Schema{
+		Type:     schema.TypeSet,
+		Optional: true,
+		Elem: &schema.Resource{
+			Schema: endpointsAttributes,
+		},
+	}
+}
@@ -0,0 +1,113 @@
+package appstream
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+)
+
+func TestProvider(t *testing.T) {
+	if err := Provider().(*schema.Provider).InternalValidate(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
+
+func TestProvider_impl(t *testing.T) {
+	var _ terraform.ResourceProvider = Provider()
+}
+
+// testCheckSchemaReplacement asserts that every configurable attribute of r is classified
+// as either replaced or updated in place, and that ForceNew agrees down to nested blocks.
+func testCheckSchemaReplacement(t *testing.T, r *schema.Resource, replace []string, inPlace []string) {
+	t.Helper()
+
+	classified := make(map[string]bool)
+	for _, k := range replace {
+		classified[k] = true
+	}
+	for _, k := range inPlace {
+		if _, ok := classified[k]; ok {
+			t.Errorf("%s is classified both as replaced and updated in place", k)
+		}
+		classified[k] = false
+	}
+
+	keys := make([]string, 0, len(r.Schema))
+	for k := range r.Schema {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		s := r.Schema[k]
+		if !s.Required && !s.Optional {
+			continue
+		}
+		forceNew, ok := classified[k]
+		if !ok {
+			t.Errorf("%s is not classified as replaced or updated in place", k)
+			continue
+		}
+		testCheckSchemaForceNew(t, k, s, forceNew)
+	}
+
+	for k := range classified {
+		if _, ok := r.Schema[k]; !ok {
+			t.Errorf("%s is classified but not in the schema", k)
+		}
+	}
+}
+
+func testCheckSchemaForceNew(t *testing.T, k string, s *schema.Schema, forceNew bool) {
+	t.Helper()
+
+	if !s.Required && !s.Optional {
+		return
+	}
+	if s.ForceNew != forceNew {
+		t.Errorf("%s: expected ForceNew %t, got %t", k, forceNew, s.ForceNew)
+	}
+	if elem, ok := s.Elem.(*schema.Resource); ok {
+		for nk, ns := range elem.Schema {
+			testCheckSchemaForceNew(t, k+".0."+nk, ns, forceNew)
+		}
+	}
+}
+
+// testResourceDiff plans a change from the attributes in state to the raw configuration.
+func testResourceDiff(t *testing.T, r *schema.Resource, state map[string]string, config map[string]interface{}) *terraform.InstanceDiff {
+	t.Helper()
+
+	diff, err := r.Diff(&terraform.InstanceState{
+		ID:         state["name"],
+		Attributes: state,
+	}, terraform.NewResourceConfigRaw(config), nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	return diff
+}
+
+// testImportState imports id through the importer of r and refreshes it, as terraform
+// import does.
+func testImportState(t *testing.T, r *schema.Resource, id string, meta interface{}) *terraform.InstanceState {
+	t.Helper()
+
+	imported, err := r.Importer.State(r.Data(&terraform.InstanceState{ID: id}), meta)
+	if err != nil {
+		t.Fatalf("error importing: %s", err)
+	}
+	if len(imported) != 1 {
+		t.Fatalf("expected one imported resource, got %d", len(imported))
+	}
+	state, err := r.RefreshWithoutUpgrade(imported[0].State(), meta)
+	if err != nil {
+		t.Fatalf("error refreshing: %s", err)
+	}
+	if state == nil || state.ID != id {
+		t.Fatalf("expected %s to be imported, got %v", id, state)
+	}
+	return state
+}
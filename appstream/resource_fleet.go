@@ -1,140 +1,226 @@
 package appstream
 
 import (
-        "github.com/aws/aws-sdk-go/aws"
-        "github.com/aws/aws-sdk-go/service/appstream"
-        "github.com/hashicorp/terraform-plugin-sdk/helper/schema"
-    	"log"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/appstream"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/customdiff"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	"log"
 	"strings"
 	"time"
 )
 
+const (
+	// scaleDownProtectionDisabled lowers desired capacity without looking at sessions in use.
+	scaleDownProtectionDisabled = "DISABLED"
+	// scaleDownProtectionFail refuses to lower desired capacity below the sessions in use.
+	scaleDownProtectionFail = "FAIL"
+	// scaleDownProtectionWait waits until the sessions in use fit in the new desired capacity.
+	scaleDownProtectionWait = "WAIT"
+)
+
+// fleetStoppedOnlyAttributes are the arguments UpdateFleet refuses to change on a running fleet.
+var fleetStoppedOnlyAttributes = []string{
+	"description",
+	"domain_info",
+	"enable_default_internet_access",
+	"iam_role_arn",
+	"instance_type",
+	"max_user_duration",
+	"vpc_config",
+}
 
 func resourceAppstreamFleet() *schema.Resource {
-	return &schema.Resource {
-        Create: resourceAppstreamFleetCreate,
-        Read:   resourceAppstreamFleetRead,
-        Update: resourceAppstreamFleetUpdate,
-        Delete: resourceAppstreamFleetDelete,
-        Importer: &schema.ResourceImporter {
-            State: schema.ImportStatePassthrough,
-        },
-
-        Schema: map[string]*schema.Schema{
-            "compute_capacity": {
-                Type:         schema.TypeList,
-                Required:     true,
-                Elem:         &schema.Resource{
-                    Schema: map[string]*schema.Schema{
-                        "desired_instances": {
-                            Type:       schema.TypeInt,
-                            Required:   true,
-                        },
-                    },
-                },
-            },
-
-            "description": {
-                Type:         schema.TypeString,
-                Optional:     true,
-            },
-
-            "disconnect_timeout": {
-                Type:         schema.TypeInt,
-                Optional:     true,
-            },
-
-            "display_name": {
-                Type:         schema.TypeString,
-                Optional:     true,
-            },
-
-            "domain_info": {
-                Type:         schema.TypeList,
-                Optional:     true,
-                Elem:         &schema.Resource{
-                    Schema: map[string]*schema.Schema{
-                        "directory_name": {
-                            Type:       schema.TypeString,
-                            Optional:   true,
-                        },
-                        "organizational_unit_distinguished_name": {
-                            Type:       schema.TypeString,
-                            Optional:   true,
-                        },
-                    },
-                },
-            },
-
-            "enable_default_internet_access": {
-                Type:         schema.TypeBool,
-                Optional:     true,
-            },
-
-            "fleet_type": {
-                Type:         schema.TypeString,
-                Optional:     true,
-            },
+	return &schema.Resource{
+		Create: resourceAppstreamFleetCreate,
+		Read:   resourceAppstreamFleetRead,
+		Update: resourceAppstreamFleetUpdate,
+		Delete: resourceAppstreamFleetDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		SchemaVersion: 1,
+		StateUpgraders: []schema.StateUpgrader{
+			{
+				Type:    resourceAppstreamFleetV0().CoreConfigSchema().ImpliedType(),
+				Upgrade: resourceAppstreamFleetStateUpgradeV0,
+				Version: 0,
+			},
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(60 * time.Minute),
+			Update: schema.DefaultTimeout(60 * time.Minute),
+			Delete: schema.DefaultTimeout(30 * time.Minute),
+		},
+
+		CustomizeDiff: customdiff.Sequence(
+			resourceAppstreamFleetCustomizeDiffInstanceType,
+			resourceAppstreamFleetCustomizeDiffWaitForCapacity,
+			resourceAppstreamFleetCustomizeDiffScaleDown,
+		),
+
+		Schema: map[string]*schema.Schema{
+			"compute_capacity": {
+				Type:     schema.TypeList,
+				Required: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"desired_instances": {
+							Type:             schema.TypeInt,
+							Required:         true,
+							DiffSuppressFunc: suppressExternallyManagedCapacity,
+							ValidateFunc:     validation.IntAtLeast(0),
+						},
+					},
+				},
+			},
+
+			"capacity_managed_externally": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+
+			// Tagged on the fleet; create only adopts an existing fleet carrying the same token.
+			// Defaults to the name, so an interrupted create is resumed without setting it.
+			"creation_token": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.StringLenBetween(1, 256),
+			},
+
+			"deletion_protection": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+
+			"description": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringLenBetween(0, 256),
+			},
+
+			"disconnect_timeout": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntBetween(60, 36000),
+			},
+
+			"desired_state": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{appstream.FleetStateRunning, appstream.FleetStateStopped}, false),
+			},
+
+			"display_name": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringLenBetween(0, 100),
+			},
+
+			"domain_info": domainInfoSchema(false),
+
+			"enable_default_internet_access": {
+				Type:     schema.TypeBool,
+				Optional: true,
+			},
+
+			"fleet_type": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringInSlice(appstream.FleetType_Values(), false),
+			},
 
 			"image_arn": {
-                Type:         schema.TypeString,
+				Type:         schema.TypeString,
 				Required:     true,
-				ForceNew:	  true,
+				ValidateFunc: validateAppstreamImageArn,
 			},
 
 			"iam_role_arn": {
-                Type:         schema.TypeString,
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validateIamRoleArn,
+			},
+
+			"instance_type": {
+				Type:         schema.TypeString,
 				Required:     true,
-				ForceNew:	  true,
-            },
-
-            "instance_type": {
-                Type:         schema.TypeString,
-                Required:     true,
-            },
-
-            "max_user_duration": {
-                Type:         schema.TypeInt,
-                Optional:     true,
-            },
-
-            "name": {
-                Type:       schema.TypeString,
-                Required:   true,
-            },
-
-	    "stack_name": {
-                Type:       schema.TypeString,
-                Optional:   true,
-            },
-
-	    "state": {
-		    Type:	schema.TypeString,
-		    Optional:	true,
-	    },
-
-            "vpc_config": {
-                Type:         schema.TypeList,
-                Optional:     true,
-                Elem:         &schema.Resource {
-                    Schema: map[string]*schema.Schema {
-                        "security_group_ids": {
-                            Type:       schema.TypeString,
-                            Optional:   true,
-                        },
-                        "subnet_ids":   {
-                            Type:       schema.TypeString,
-                            Optional:   true,
-                        },
-                    },
-                },
-            },
-	    "tags": {
-		    Type:	schema.TypeMap,
-		    Optional:	true,
-	    },
-        },
-    }
+				ValidateFunc: validateAppstreamInstanceType,
+			},
+
+			"max_user_duration": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntBetween(600, 432000),
+			},
+
+			"name": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateAppstreamName,
+			},
+
+			"scale_down_protection": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  scaleDownProtectionDisabled,
+				ValidateFunc: validation.StringInSlice([]string{
+					scaleDownProtectionDisabled,
+					scaleDownProtectionFail,
+					scaleDownProtectionWait,
+				}, false),
+			},
+
+			"stack_name": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateAppstreamName,
+			},
+
+			"state": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"vpc_config": vpcConfigSchema(false),
+
+			"wait_for_capacity": {
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						// 0 waits for the desired capacity of the fleet.
+						"min_available": {
+							Type:         schema.TypeInt,
+							Optional:     true,
+							ValidateFunc: validation.IntAtLeast(0),
+						},
+						"timeout": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "15m",
+							ValidateFunc: validateDuration,
+						},
+					},
+				},
+			},
+			"tags": {
+				Type:     schema.TypeMap,
+				Optional: true,
+			},
+		},
+	}
 }
 
 func resourceAppstreamFleetCreate(d *schema.ResourceData, meta interface{}) error {
@@ -157,7 +243,6 @@ func resourceAppstreamFleetCreate(d *schema.ResourceData, meta interface{}) erro
 		CreateFleetInputOpts.ComputeCapacity = ComputeConfig
 	}
 
-
 	if v, ok := d.GetOk("description"); ok {
 		CreateFleetInputOpts.Description = aws.String(v.(string))
 	}
@@ -170,18 +255,8 @@ func resourceAppstreamFleetCreate(d *schema.ResourceData, meta interface{}) erro
 		CreateFleetInputOpts.DisplayName = aws.String(v.(string))
 	}
 
-	DomainJoinInfoConfig := &appstream.DomainJoinInfo{}
-
-	if dom, ok := d.GetOk("domain_info"); ok {
-		DomainAttributes := dom.([]interface{})
-		attr := DomainAttributes[0].(map[string]interface{})
-		if v, ok := attr["directory_name"]; ok {
-			DomainJoinInfoConfig.DirectoryName = aws.String(v.(string))
-		}
-		if v, ok := attr["organizational_unit_distinguished_name"]; ok {
-			DomainJoinInfoConfig.OrganizationalUnitDistinguishedName = aws.String(v.(string))
-		}
-		CreateFleetInputOpts.DomainJoinInfo = DomainJoinInfoConfig
+	if v, ok := d.GetOk("domain_info"); ok {
+		CreateFleetInputOpts.DomainJoinInfo = expandDomainJoinInfo(v.([]interface{}))
 	}
 
 	if v, ok := d.GetOk("enable_default_internet_access"); ok {
@@ -197,10 +272,9 @@ func resourceAppstreamFleetCreate(d *schema.ResourceData, meta interface{}) erro
 	}
 
 	if v, ok := d.GetOk("iam_role_arn"); ok {
-		CreateFleetInputOpts.IamRoleArn  = aws.String(v.(string))
+		CreateFleetInputOpts.IamRoleArn = aws.String(v.(string))
 	}
 
-
 	if v, ok := d.GetOk("instance_type"); ok {
 		CreateFleetInputOpts.InstanceType = aws.String(v.(string))
 	}
@@ -209,79 +283,90 @@ func resourceAppstreamFleetCreate(d *schema.ResourceData, meta interface{}) erro
 		CreateFleetInputOpts.MaxUserDurationInSeconds = aws.Int64(int64(v.(int)))
 	}
 
-	VpcConfigConfig := & appstream.VpcConfig{}
-
-	if vpc, ok := d.GetOk("vpc_config"); ok {
-        	VpcAttributes := vpc.([]interface{})
-        	attr := VpcAttributes[0].(map[string]interface{})
-        	if v, ok := attr["security_group_ids"]; ok {
-            		strSlice := strings.Split(v.(string), ",")
-                	for i, s := range strSlice {
-		    		strSlice[i] = strings.TrimSpace(s)
-			}
-            		VpcConfigConfig.SecurityGroupIds = aws.StringSlice(strSlice)
-        	}
-        	if v, ok := attr["subnet_ids"]; ok {
-            		strSlice := strings.Split(v.(string), ",")
-                	for i, s := range strSlice {
-		    		strSlice[i] = strings.TrimSpace(s)
-			}
-            		VpcConfigConfig.SubnetIds = aws.StringSlice(strSlice)
-    		}
-        	CreateFleetInputOpts.VpcConfig = VpcConfigConfig
-    	}
+	if v, ok := d.GetOk("vpc_config"); ok {
+		CreateFleetInputOpts.VpcConfig = expandVpcConfig(v.([]interface{}))
+	}
 
-	log.Printf("[DEBUG] Run configuration: %s", CreateFleetInputOpts)
-	resp, err := svc.CreateFleet(CreateFleetInputOpts)
+	tags := New(d.Get("tags").(map[string]interface{})).IgnoreAWS().IgnoreProvider()
+	tags = tags.Merge(deletionProtectionTags(d.Get("deletion_protection").(bool)))
+	token := d.Get("creation_token").(string)
+	if token == "" {
+		token = defaultCreationToken(d.Get("name").(string))
+	}
+	tags = tags.Merge(managedByTags(token))
+	CreateFleetInputOpts.Tags = Tags(tags)
 
+	fleet, err := describeFleet(svc, aws.StringValue(CreateFleetInputOpts.Name))
 	if err != nil {
-		log.Printf("[ERROR] Error creating Appstream Fleet: %s", err)
 		return err
 	}
 
-	log.Printf("[DEBUG] %s", resp)
-	time.Sleep(2 * time.Second)
-	if v, ok := d.GetOk("tags"); ok {
-
-		data_tags := v.(map[string]interface{})
-
-		attr := make(map[string]string)
+	stacks := make([]string, 0)
+	if fleet != nil {
+		// A fleet tagged with the same creation_token is left over from a create of this
+		// configuration that was interrupted before it reached state. Adopt it and let Read
+		// and the next plan reconcile its arguments instead of failing on a name conflict.
+		// A fleet created outside the provider has no token and is never adopted.
+		owner, err := managedByToken(svc, aws.StringValue(fleet.Arn))
+		if err != nil {
+			return err
+		}
+		if owner != token {
+			return fmt.Errorf("Appstream Fleet (%s) already exists and was not created with this creation_token, import it instead", aws.StringValue(fleet.Name))
+		}
+		log.Printf("[INFO] Adopting Appstream Fleet (%s) left behind by an interrupted create", aws.StringValue(fleet.Name))
 
-		for k, v := range data_tags {
-		    attr[k] = v.(string)
+		stacks, err = listAssociatedStacks(svc, aws.StringValue(fleet.Name))
+		if err != nil {
+			return err
 		}
 
-		tags := aws.StringMap(attr)
+		d.SetId(aws.StringValue(fleet.Name))
+		d.Partial(true)
+		d.SetPartial("creation_token")
+		d.SetPartial("name")
+	} else {
+		log.Printf("[DEBUG] Run configuration: %s", CreateFleetInputOpts)
+		resp, err := svc.CreateFleet(CreateFleetInputOpts)
 
-		fleet_name := aws.StringValue(CreateFleetInputOpts.Name)
-		get, err := svc.DescribeFleets(&appstream.DescribeFleetsInput {
-		    Names:   aws.StringSlice([]string{fleet_name}),
-		})
 		if err != nil {
-		    log.Printf("[ERROR] Error describing Appstream Fleet: %s", err)
-		    return err
-		}
-		if get.Fleets == nil {
-		    log.Printf("[DEBUG] Apsstream Fleet (%s) not found", d.Id())
+			log.Printf("[ERROR] Error creating Appstream Fleet: %s", err)
+			return err
 		}
 
-		fleetArn := get.Fleets[0].Arn
+		log.Printf("[DEBUG] %s", resp)
 
-		tag, err := svc.TagResource(&appstream.TagResourceInput{
-		    ResourceArn:    fleetArn,
-		    Tags:           tags,
-		})
-		if err != nil {
-		    log.Printf("[ERROR] Error tagging Appstream Stack: %s", err)
-		    return err
+		// Save the fleet as soon as it exists. Partial mode keeps the follow-up steps below out
+		// of state until they succeed, so a failed step shows up as a diff on the next plan
+		// instead of leaving an untracked fleet behind.
+		d.SetId(aws.StringValue(CreateFleetInputOpts.Name))
+		d.Partial(true)
+		for _, k := range []string{"compute_capacity", "creation_token", "deletion_protection", "description", "disconnect_timeout", "display_name",
+			"domain_info", "enable_default_internet_access", "fleet_type", "image_arn", "iam_role_arn", "instance_type",
+			"max_user_duration", "name", "tags", "vpc_config"} {
+			d.SetPartial(k)
 		}
-		log.Printf("[DEBUG] %s", tag)
 	}
 
+	d.Set("creation_token", token)
+
+	// A failed step is returned with the ID and the finished steps still set, so the fleet
+	// stays in state and the steps left out of it show up as changes on the next plan.
+	if err := resourceAppstreamFleetCreateSteps(d, svc, stacks, fleet != nil); err != nil {
+		log.Printf("[ERROR] Error finishing Appstream Fleet (%s) creation: %s", d.Id(), err)
+		return err
+	}
+	d.Partial(false)
 
-	if v, ok := d.GetOk("stack_name"); ok {
+	return resourceAppstreamFleetRead(d, meta)
+}
+
+// resourceAppstreamFleetCreateSteps associates, starts and waits for a fleet that was just
+// created or adopted. Each step is saved to state with SetPartial once it succeeds.
+func resourceAppstreamFleetCreateSteps(d *schema.ResourceData, svc *appstream.AppStream, stacks []string, adopted bool) error {
+	if v, ok := d.GetOk("stack_name"); ok && !stringInSlice(v.(string), stacks) {
 		AssociateFleetInputOpts := &appstream.AssociateFleetInput{}
-		AssociateFleetInputOpts.FleetName = CreateFleetInputOpts.Name
+		AssociateFleetInputOpts.FleetName = aws.String(d.Id())
 		AssociateFleetInputOpts.StackName = aws.String(v.(string))
 		resp, err := svc.AssociateFleet(AssociateFleetInputOpts)
 		if err != nil {
@@ -291,315 +376,701 @@ func resourceAppstreamFleetCreate(d *schema.ResourceData, meta interface{}) erro
 
 		log.Printf("[DEBUG] %s", resp)
 	}
+	d.SetPartial("stack_name")
 
-	if v, ok := d.GetOk("state"); ok {
-		if v == "RUNNING" {
-			desired_state := v
-			resp, err := svc.StartFleet(&appstream.StartFleetInput{
-				Name: CreateFleetInputOpts.Name,
-			})
+	if v, ok := d.GetOk("desired_state"); ok {
+		if err := reconcileAppstreamFleetState(svc, d.Id(), v.(string), d.Timeout(schema.TimeoutCreate)); err != nil {
+			return err
+		}
+	} else if adopted {
+		if _, err := waitForAppstreamFleetStable(svc, d.Id(), d.Timeout(schema.TimeoutCreate)); err != nil {
+			return err
+		}
+	}
+	d.SetPartial("desired_state")
 
-			if err != nil {
-				log.Printf("[ERROR] Error satrting Appstream Fleet: %s", err)
-				return err
-			}
-			log.Printf("[DEBUG] %s", resp)
+	if err := waitForAppstreamFleetCapacityConfig(d, svc); err != nil {
+		return err
+	}
+	d.SetPartial("wait_for_capacity")
 
-			for {
+	return nil
+}
 
-			    resp, err := svc.DescribeFleets(&appstream.DescribeFleetsInput{
-				Names:  aws.StringSlice([]string{*CreateFleetInputOpts.Name}),
-			    })
+func resourceAppstreamFleetRead(d *schema.ResourceData, meta interface{}) error {
+	svc := meta.(*AWSClient).appstreamconn
 
-			    if err != nil {
-				log.Printf("[ERROR] Error describing Appstream Fleet: %s", err)
-				return err
-			    }
+	v, err := describeFleet(svc, d.Id())
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		log.Printf("[WARN] Appstream Fleet (%s) not found, removing from state", d.Id())
+		d.SetId("")
+		return nil
+	}
 
-			    curr_state := resp.Fleets[0].State
-			    if aws.StringValue(curr_state) == desired_state{
-				break
-			    }
-			    if aws.StringValue(curr_state) != desired_state {
-				time.Sleep(20 * time.Second)
-				continue
-			    }
+	d.Set("name", v.Name)
 
-			}
+	if v.ComputeCapacityStatus != nil {
+		comp_attr := map[string]interface{}{}
+		comp_attr["desired_instances"] = aws.Int64Value(v.ComputeCapacityStatus.Desired)
+		if err := d.Set("compute_capacity", []interface{}{comp_attr}); err != nil {
+			log.Printf("[ERROR] Error setting compute capacity: %s", err)
+			return err
 		}
 	}
 
-	d.SetId(*CreateFleetInputOpts.Name)
+	d.Set("description", v.Description)
+	d.Set("display_name", v.DisplayName)
+	d.Set("disconnect_timeout", v.DisconnectTimeoutInSeconds)
+	d.Set("enable_default_internet_access", v.EnableDefaultInternetAccess)
+	d.Set("fleet_type", v.FleetType)
+	d.Set("image_arn", v.ImageArn)
+	d.Set("iam_role_arn", v.IamRoleArn)
+	d.Set("instance_type", v.InstanceType)
+	d.Set("max_user_duration", v.MaxUserDurationInSeconds)
+
+	if err := d.Set("domain_info", flattenDomainJoinInfo(v.DomainJoinInfo)); err != nil {
+		log.Printf("[ERROR] Error setting domain info: %s", err)
+		return err
+	}
+	if err := d.Set("vpc_config", flattenVpcConfig(v.VpcConfig)); err != nil {
+		log.Printf("[ERROR] Error setting vpc config: %s", err)
+		return err
+	}
+	tg, err := svc.ListTagsForResource(&appstream.ListTagsForResourceInput{
+		ResourceArn: v.Arn,
+	})
+	if err != nil {
+		log.Printf("[ERROR] Error listing fleet tags: %s", err)
+		return err
+	}
+
+	d.Set("creation_token", aws.StringValue(tg.Tags[managedByTagKey]))
+	d.Set("deletion_protection", aws.StringValue(tg.Tags[deletionProtectionTagKey]) == "true")
 
-	return resourceAppstreamFleetRead(d, meta)
+	if err := d.Set("tags", New(tg.Tags).IgnoreAWS().IgnoreProvider().Map()); err != nil {
+		log.Printf("[ERROR] Error setting fleet tags: %s", err)
+		return err
+	}
+
+	d.Set("state", v.State)
+	if d.Get("desired_state").(string) != "" {
+		d.Set("desired_state", stableFleetState(aws.StringValue(v.State), d.Get("desired_state").(string)))
+	}
+
+	stacks, err := listAssociatedStacks(svc, aws.StringValue(v.Name))
+	if err != nil {
+		return err
+	}
+	stack_name := ""
+	for _, stack := range stacks {
+		if stack_name == "" || stack == d.Get("stack_name").(string) {
+			stack_name = stack
+		}
+	}
+	d.Set("stack_name", stack_name)
+
+	return nil
 }
 
-func resourceAppstreamFleetRead(d *schema.ResourceData, meta interface{}) error {
-	svc := meta.(*AWSClient).appstreamconn
+func resourceAppstreamFleetUpdate(d *schema.ResourceData, meta interface{}) error {
 
-	resp, err := svc.DescribeFleets(&appstream.DescribeFleetsInput{})
+	svc := meta.(*AWSClient).appstreamconn
+	UpdateFleetInputOpts := &appstream.UpdateFleetInput{}
 
+	// A fleet still STARTING or STOPPING, for example after an interrupted apply, is waited
+	// for rather than updated mid-transition; the state block below then reconciles it.
+	timeout := d.Timeout(schema.TimeoutUpdate)
+	fleet, err := waitForAppstreamFleetStable(svc, d.Id(), timeout)
 	if err != nil {
-		log.Printf("[ERROR] Error reading Appstream Fleet: %s", err)
 		return err
 	}
-	for _, v := range resp.Fleets {
-		if aws.StringValue(v.Name) == d.Get("name") {
 
-			d.Set("name", v.Name)
+	d.Partial(true)
 
-			if v.ComputeCapacityStatus != nil {
-				comp_attr := map[string]interface{}{}
-				comp_attr["desired_instances"] = aws.Int64Value(v.ComputeCapacityStatus.Desired)
-				d.Set("compute_capacity", comp_attr)
+	// A running fleet only accepts changes to its capacity, image, display name and
+	// timeouts. A fleet asked to stop is stopped before UpdateFleet, one that should keep
+	// running is stopped for the update and started again afterwards.
+	restart := false
+	if d.HasChange("desired_state") && d.Get("desired_state").(string) == appstream.FleetStateStopped {
+		if err := reconcileAppstreamFleetState(svc, d.Id(), appstream.FleetStateStopped, timeout); err != nil {
+			return err
+		}
+		d.SetPartial("desired_state")
+	} else if d.HasChanges(fleetStoppedOnlyAttributes...) && aws.StringValue(fleet.State) == appstream.FleetStateRunning {
+		log.Printf("[INFO] Stopping Appstream Fleet (%s) to update it", d.Id())
+		if err := reconcileAppstreamFleetState(svc, d.Id(), appstream.FleetStateStopped, timeout); err != nil {
+			return err
+		}
+		restart = true
+	}
+
+	if v, ok := d.GetOk("name"); ok {
+		UpdateFleetInputOpts.Name = aws.String(v.(string))
+	}
+
+	if d.HasChange("description") {
+		d.SetPartial("description")
+		log.Printf("[DEBUG] Modify Fleet")
+		description := d.Get("description").(string)
+		UpdateFleetInputOpts.Description = aws.String(description)
+	}
+
+	if d.HasChange("disconnect_timeout") {
+		d.SetPartial("disconnect_timeout")
+		log.Printf("[DEBUG] Modify Fleet")
+		disconnect_timeout := d.Get("disconnect_timeout").(int)
+		UpdateFleetInputOpts.DisconnectTimeoutInSeconds = aws.Int64(int64(disconnect_timeout))
+	}
+
+	if d.HasChange("display_name") {
+		d.SetPartial("display_name")
+		log.Printf("[DEBUG] Modify Fleet")
+		display_name := d.Get("display_name").(string)
+		UpdateFleetInputOpts.DisplayName = aws.String(display_name)
+	}
+
+	if d.HasChange("compute_capacity") && !d.Get("capacity_managed_externally").(bool) {
+		d.SetPartial("compute_capacity")
+		log.Printf("[DEBUG] Modify Fleet")
+		desired_instances := int64(d.Get("compute_capacity.0.desired_instances").(int))
+		if err := waitForFleetScaleDown(d, svc, desired_instances); err != nil {
+			return err
+		}
+		UpdateFleetInputOpts.ComputeCapacity = &appstream.ComputeCapacity{
+			DesiredInstances: aws.Int64(desired_instances),
+		}
+	}
+
+	if d.HasChange("domain_info") {
+		d.SetPartial("domain_info")
+		log.Printf("[DEBUG] Modify Fleet")
+		if v, ok := d.GetOk("domain_info"); ok {
+			UpdateFleetInputOpts.DomainJoinInfo = expandDomainJoinInfo(v.([]interface{}))
+		} else {
+			UpdateFleetInputOpts.AttributesToDelete = append(UpdateFleetInputOpts.AttributesToDelete, aws.String(appstream.FleetAttributeDomainJoinInfo))
+		}
+	}
+
+	if d.HasChange("enable_default_internet_access") {
+		d.SetPartial("enable_default_internet_access")
+		log.Printf("[DEBUG] Modify Fleet")
+		enable_default_internet_access := d.Get("enable_default_internet_access").(bool)
+		UpdateFleetInputOpts.EnableDefaultInternetAccess = aws.Bool(enable_default_internet_access)
+	}
+
+	if d.HasChange("image_arn") {
+		d.SetPartial("image_arn")
+		log.Printf("[DEBUG] Modify Fleet")
+		image_arn := d.Get("image_arn").(string)
+		UpdateFleetInputOpts.ImageArn = aws.String(image_arn)
+	}
+
+	if d.HasChange("iam_role_arn") {
+		d.SetPartial("iam_role_arn")
+		log.Printf("[DEBUG] Modify Fleet")
+		iam_role_arn := d.Get("iam_role_arn").(string)
+		UpdateFleetInputOpts.IamRoleArn = aws.String(iam_role_arn)
+	}
+
+	if d.HasChange("instance_type") {
+		d.SetPartial("instance_type")
+		log.Printf("[DEBUG] Modify Fleet")
+		instance_type := d.Get("instance_type").(string)
+		UpdateFleetInputOpts.InstanceType = aws.String(instance_type)
+	}
+
+	if d.HasChange("max_user_duration") {
+		d.SetPartial("max_user_duration")
+		log.Printf("[DEBUG] Modify Fleet")
+		max_user_duration := d.Get("max_user_duration").(int)
+		UpdateFleetInputOpts.MaxUserDurationInSeconds = aws.Int64(int64(max_user_duration))
+	}
+
+	if d.HasChange("vpc_config") {
+		d.SetPartial("vpc_config")
+		log.Printf("[DEBUG] Modify Fleet")
+		if v, ok := d.GetOk("vpc_config"); ok {
+			UpdateFleetInputOpts.VpcConfig = expandVpcConfig(v.([]interface{}))
+		} else {
+			UpdateFleetInputOpts.AttributesToDelete = append(UpdateFleetInputOpts.AttributesToDelete, aws.String(appstream.FleetAttributeVpcConfiguration))
+		}
+	}
+
+	resp, err := svc.UpdateFleet(UpdateFleetInputOpts)
+
+	if err != nil {
+		log.Printf("[ERROR] Error updating Appstream Fleet: %s", err)
+		if restart {
+			if err := reconcileAppstreamFleetState(svc, d.Id(), appstream.FleetStateRunning, timeout); err != nil {
+				log.Printf("[ERROR] Error starting Appstream Fleet (%s) again after the failed update: %s", d.Id(), err)
 			}
+		}
+		return err
+	}
+
+	if d.HasChange("tags") {
+		arn := aws.StringValue(resp.Fleet.Arn)
+
+		o, n := d.GetChange("tags")
+		if err := UpdateTags(svc, arn, o, n); err != nil {
+			return err
+		}
+	}
+
+	if d.HasChange("deletion_protection") {
+		arn := aws.StringValue(resp.Fleet.Arn)
+
+		if err := updateDeletionProtection(svc, arn, d.Get("deletion_protection").(bool)); err != nil {
+			return err
+		}
+	}
 
-			d.Set("description", v.Description)
-			d.Set("display_name", v.DisplayName)
-			d.Set("disconnect_timeout", v.DisconnectTimeoutInSeconds)
-			d.Set("enable_default_internet_access", v.EnableDefaultInternetAccess)
-			d.Set("fleet_type", v.FleetType)
-			d.Set("image_arn", v.ImageArn)
-			d.Set("iam_role_arn", v.IamRoleArn)
-			d.Set("instance_type", v.InstanceType)
-			d.Set("max_user_duration", v.MaxUserDurationInSeconds)
-
-			if v.VpcConfig != nil {
-				vpc_attr := map[string]interface{}{}
-				vpc_config_sg := aws.StringValueSlice(v.VpcConfig.SecurityGroupIds)
-				vpc_config_sub := aws.StringValueSlice(v.VpcConfig.SubnetIds)
-				vpc_attr["security_group_ids"] = aws.String(strings.Join(vpc_config_sg, ","))
-				vpc_attr["subnet_ids"] = aws.String(strings.Join(vpc_config_sub, ","))
-				d.Set("vpc_config", vpc_attr)
+	if d.HasChange("creation_token") {
+		if err := updateManagedBy(svc, aws.StringValue(resp.Fleet.Arn), d.Get("creation_token").(string)); err != nil {
+			return err
+		}
+	}
+	log.Printf("[DEBUG] %s", resp)
+
+	if d.HasChange("stack_name") {
+		o, n := d.GetChange("stack_name")
+		if o.(string) != "" {
+			dis, err := svc.DisassociateFleet(&appstream.DisassociateFleetInput{
+				FleetName: aws.String(d.Id()),
+				StackName: aws.String(o.(string)),
+			})
+			if err != nil && !isAWSErr(err, appstream.ErrCodeResourceNotFoundException, "") {
+				log.Printf("[ERROR] Error disassociating Appstream Fleet: %s", err)
+				return err
 			}
-			tg, err := svc.ListTagsForResource(&appstream.ListTagsForResourceInput{
-        			ResourceArn: v.Arn,
-    			})
-    			if err != nil {
-				log.Printf("[ERROR] Error listing stack tags: %s", err)
+			log.Printf("[DEBUG] %s", dis)
+		}
+		if n.(string) != "" {
+			ass, err := svc.AssociateFleet(&appstream.AssociateFleetInput{
+				FleetName: aws.String(d.Id()),
+				StackName: aws.String(n.(string)),
+			})
+			if err != nil {
+				log.Printf("[ERROR] Error associating Appstream Fleet: %s", err)
 				return err
-    			}
-			if tg.Tags == nil {
-				log.Printf("[DEBUG] Apsstream Stack tags (%s) not found", d.Id())
-				return nil
 			}
+			log.Printf("[DEBUG] %s", ass)
+		}
+		d.SetPartial("stack_name")
+	}
+
+	if restart {
+		if err := reconcileAppstreamFleetState(svc, d.Id(), appstream.FleetStateRunning, timeout); err != nil {
+			return err
+		}
+	}
 
-			if len(tg.Tags) > 0 {
-				tags_attr := make(map[string]string)
-				tags := tg.Tags
-				for k, v := range tags {
-					tags_attr[k] = aws.StringValue(v)
-				}
-				d.Set("tags", tags_attr)
+	if d.HasChange("desired_state") {
+		if v := d.Get("desired_state").(string); v != "" {
+			if err := reconcileAppstreamFleetState(svc, d.Id(), v, timeout); err != nil {
+				return err
 			}
+		}
+		d.SetPartial("desired_state")
+	}
 
-			d.Set("state", v.State)
+	if err := waitForAppstreamFleetCapacityConfig(d, svc); err != nil {
+		return err
+	}
+	d.SetPartial("wait_for_capacity")
 
-			return nil
+	d.Partial(false)
+	return resourceAppstreamFleetRead(d, meta)
+
+}
+
+func resourceAppstreamFleetDelete(d *schema.ResourceData, meta interface{}) error {
+
+	svc := meta.(*AWSClient).appstreamconn
+
+	fleet, err := describeFleet(svc, d.Id())
+	if err != nil {
+		return err
+	}
+	if fleet == nil {
+		log.Printf("[DEBUG] Appstream Fleet (%s) already deleted", d.Id())
+		return nil
+	}
+
+	if d.Get("deletion_protection").(bool) {
+		return fmt.Errorf("Appstream Fleet (%s) has deletion_protection enabled, set it to false and apply before destroying", d.Id())
+	}
+	if err := checkDeletionProtection(svc, aws.StringValue(fleet.Arn)); err != nil {
+		return err
+	}
+
+	timeout := d.Timeout(schema.TimeoutDelete)
 
+	switch aws.StringValue(fleet.State) {
+	case appstream.FleetStateStarting:
+		if _, err := waitForAppstreamFleetState(svc, d.Id(), appstream.FleetStateRunning, timeout); err != nil {
+			return err
+		}
+		fallthrough
+	case appstream.FleetStateRunning:
+		stop, err := svc.StopFleet(&appstream.StopFleetInput{
+			Name: aws.String(d.Id()),
+		})
+		if err != nil {
+			log.Printf("[ERROR] Error stopping Appstream Fleet: %s", err)
+			return err
 		}
+		log.Printf("[DEBUG] %s", stop)
+		fallthrough
+	case appstream.FleetStateStopping:
+		if _, err := waitForAppstreamFleetState(svc, d.Id(), appstream.FleetStateStopped, timeout); err != nil {
+			return err
+		}
+	}
+
+	stacks, err := listAssociatedStacks(svc, d.Id())
+	if err != nil {
+		return err
+	}
+	for _, stack := range stacks {
+		dis, err := svc.DisassociateFleet(&appstream.DisassociateFleetInput{
+			FleetName: aws.String(d.Id()),
+			StackName: aws.String(stack),
+		})
+		if err != nil && !isAWSErr(err, appstream.ErrCodeResourceNotFoundException, "") {
+			log.Printf("[ERROR] Error disassociating Appstream Fleet from stack %s: %s", stack, err)
+			return err
+		}
+		log.Printf("[DEBUG] %s", dis)
+	}
+
+	del, err := svc.DeleteFleet(&appstream.DeleteFleetInput{
+		Name: aws.String(d.Id()),
+	})
+	if isAWSErr(err, appstream.ErrCodeResourceNotFoundException, "") {
+		return nil
+	}
+	if err != nil {
+		log.Printf("[ERROR] Error deleting Appstream Fleet: %s", err)
+		return err
+	}
+	log.Printf("[DEBUG] %s", del)
+
+	deadline := time.Now().Add(timeout)
+	for {
+		fleet, err := describeFleet(svc, d.Id())
+		if err != nil {
+			return err
+		}
+		if fleet == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timeout waiting for Appstream Fleet (%s) to be deleted", d.Id())
+		}
+		log.Printf("[DEBUG] Appstream Fleet (%s) still exists, waiting for deletion", d.Id())
+		time.Sleep(10 * time.Second)
+	}
+}
+
+// resourceAppstreamFleetCustomizeDiffInstanceType rejects instance types the fleet type cannot run,
+// such as GPU instances on ELASTIC fleets.
+func resourceAppstreamFleetCustomizeDiffInstanceType(diff *schema.ResourceDiff, meta interface{}) error {
+	return validateAppstreamFleetInstanceType(diff.Get("instance_type").(string), diff.Get("fleet_type").(string))
+}
+
+func resourceAppstreamFleetCustomizeDiffWaitForCapacity(diff *schema.ResourceDiff, meta interface{}) error {
+	if len(diff.Get("wait_for_capacity").([]interface{})) == 0 {
+		return nil
+	}
+	if diff.Get("fleet_type").(string) == appstream.FleetTypeElastic {
+		return fmt.Errorf("wait_for_capacity cannot be used with ELASTIC fleets, they have no compute capacity")
+	}
+	if diff.Get("desired_state").(string) == appstream.FleetStateStopped {
+		return fmt.Errorf("wait_for_capacity cannot be used with desired_state STOPPED")
+	}
+
+	// Externally managed capacity can be above the configured desired_instances.
+	if diff.Get("capacity_managed_externally").(bool) ||
+		!diff.NewValueKnown("wait_for_capacity.0.min_available") || !diff.NewValueKnown("compute_capacity.0.desired_instances") {
+		return nil
+	}
+	minAvailable := diff.Get("wait_for_capacity.0.min_available").(int)
+	desired := diff.Get("compute_capacity.0.desired_instances").(int)
+	if minAvailable > desired {
+		return fmt.Errorf("wait_for_capacity min_available (%d) must not exceed compute_capacity desired_instances (%d)", minAvailable, desired)
 	}
-	d.SetId("")
 	return nil
 }
 
-func resourceAppstreamFleetUpdate(d *schema.ResourceData, meta interface{}) error {
+func resourceAppstreamFleetCustomizeDiffScaleDown(diff *schema.ResourceDiff, meta interface{}) error {
+	if diff.Id() == "" || !diff.HasChange("compute_capacity.0.desired_instances") {
+		return nil
+	}
+	if diff.Get("scale_down_protection").(string) != scaleDownProtectionFail {
+		return nil
+	}
+
+	o, n := diff.GetChange("compute_capacity.0.desired_instances")
+	if n.(int) >= o.(int) {
+		return nil
+	}
 
 	svc := meta.(*AWSClient).appstreamconn
-    UpdateFleetInputOpts := &appstream.UpdateFleetInput{}
-
-    d.Partial(true)
-
-    if v, ok := d.GetOk("name"); ok {
-        UpdateFleetInputOpts.Name = aws.String(v.(string))
-    }
-
-    if d.HasChange("description") {
-    	d.SetPartial("description")
-        log.Printf("[DEBUG] Modify Fleet")
-        description :=d.Get("description").(string)
-        UpdateFleetInputOpts.Description = aws.String(description)
-    }
-
-    if d.HasChange("disconnect_timeout") {
-        d.SetPartial("disconnect_timeout")
-        log.Printf("[DEBUG] Modify Fleet")
-        disconnect_timeout := d.Get("disconnect_timeout").(int)
-        UpdateFleetInputOpts.DisconnectTimeoutInSeconds = aws.Int64(int64(disconnect_timeout))
-    }
-
-    if d.HasChange("display_name") {
-        d.SetPartial("display_name")
-        log.Printf("[DEBUG] Modify Fleet")
-        display_name :=d.Get("display_name").(string)
-        UpdateFleetInputOpts.DisplayName = aws.String(display_name)
-    }
+	fleet, err := describeFleet(svc, diff.Id())
+	if err != nil {
+		return err
+	}
+	if fleet == nil || fleet.ComputeCapacityStatus == nil {
+		return nil
+	}
 
-	if d.HasChange("image_arn") {
-        d.SetPartial("image_arn")
-        log.Printf("[DEBUG] Modify Fleet")
-        image_arn :=d.Get("image_arn").(string)
-        UpdateFleetInputOpts.ImageArn = aws.String(image_arn)
+	in_use := aws.Int64Value(fleet.ComputeCapacityStatus.InUse)
+	if in_use > int64(n.(int)) {
+		return fmt.Errorf("refusing to scale Appstream Fleet (%s) down to %d instances: %d streaming sessions are in use", diff.Id(), n.(int), in_use)
 	}
+	return nil
+}
 
-	if d.HasChange("iam_role_arn") {
-        d.SetPartial("iam_role_arn")
-        log.Printf("[DEBUG] Modify Fleet")
-        iam_role_arn :=d.Get("iam_role_arn").(string)
-        UpdateFleetInputOpts.IamRoleArn = aws.String(iam_role_arn)
-    }
-
-    if d.HasChange("instance_type") {
-        d.SetPartial("instance_type")
-        log.Printf("[DEBUG] Modify Fleet")
-        instance_type := d.Get("instance_type").(string)
-        UpdateFleetInputOpts.InstanceType = aws.String(instance_type)
-    }
-
-    if d.HasChange("max_user_duration") {
-        d.SetPartial("max_user_duration")
-        log.Printf("[DEBUG] Modify Fleet")
-        max_user_duration :=d.Get("max_user_duration").(int)
-        UpdateFleetInputOpts.MaxUserDurationInSeconds = aws.Int64(int64(max_user_duration))
-    }
-
-    resp, err := svc.UpdateFleet(UpdateFleetInputOpts)
-
-    if err != nil {
-        log.Printf("[ERROR] Error updating Appstream Fleet: %s", err)
-	      return err
-    }
-
-    if d.HasChange("tags") {
-      arn := aws.StringValue(resp.Fleet.Arn)
-
-      o, n := d.GetChange("tags")
-      if err := UpdateTags(svc, arn, o, n); err != nil {
-        return err
-      }
-    }
-    log.Printf("[DEBUG] %s", resp)
-    desired_state := d.Get("state")
-    if d.HasChange("state") {
-        d.SetPartial("state")
-	if desired_state == "STOPPED" {
-            svc.StopFleet(&appstream.StopFleetInput{
-		    Name: aws.String(d.Id()),
-	    })
-	    for {
-
-		    resp, err := svc.DescribeFleets(&appstream.DescribeFleetsInput{
-			Names:  aws.StringSlice([]string{*UpdateFleetInputOpts.Name}),
-		    })
-		    if err != nil {
-			log.Printf("[ERROR] Error describing Appstream Fleet: %s", err)
-			return err
-		    }
-
-		    curr_state := resp.Fleets[0].State
-		    if aws.StringValue(curr_state) == desired_state{
-			break
-		    }
-		    if aws.StringValue(curr_state) != desired_state {
-			time.Sleep(20 * time.Second)
-			continue
-		    }
-
-	    }
-        } else if desired_state == "RUNNING" {
-            svc.StartFleet(&appstream.StartFleetInput{
-		    Name: aws.String(d.Id()),
-	    })
-	    for {
-
-		    resp, err := svc.DescribeFleets(&appstream.DescribeFleetsInput{
-			Names:  aws.StringSlice([]string{*UpdateFleetInputOpts.Name}),
-		    })
-		    if err != nil {
-			log.Printf("[ERROR] Error describing Appstream Fleet: %s", err)
+// waitForFleetScaleDown applies scale_down_protection before desired capacity is lowered.
+// With FAIL the update is refused while more sessions are in use than the new capacity,
+// with WAIT it blocks until enough sessions have ended or the update timeout expires.
+func waitForFleetScaleDown(d *schema.ResourceData, svc *appstream.AppStream, desired_instances int64) error {
+	protection := d.Get("scale_down_protection").(string)
+	if protection == scaleDownProtectionDisabled {
+		return nil
+	}
+
+	o, _ := d.GetChange("compute_capacity.0.desired_instances")
+	if desired_instances >= int64(o.(int)) {
+		return nil
+	}
+
+	deadline := time.Now().Add(d.Timeout(schema.TimeoutUpdate))
+	for {
+		fleet, err := describeFleet(svc, d.Id())
+		if err != nil {
 			return err
-		    }
-
-		    curr_state := resp.Fleets[0].State
-		    if aws.StringValue(curr_state) == desired_state{
-			break
-		    }
-		    if aws.StringValue(curr_state) != desired_state {
-			time.Sleep(20 * time.Second)
-			continue
-		    }
-
-	    }
-        }
-    }
-    d.Partial(false)
-    return resourceAppstreamFleetRead(d, meta)
+		}
+		if fleet == nil || fleet.ComputeCapacityStatus == nil {
+			return nil
+		}
+
+		in_use := aws.Int64Value(fleet.ComputeCapacityStatus.InUse)
+		if in_use <= desired_instances {
+			return nil
+		}
+		if protection == scaleDownProtectionFail {
+			return fmt.Errorf("refusing to scale Appstream Fleet (%s) down to %d instances: %d streaming sessions are in use", d.Id(), desired_instances, in_use)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timeout waiting for Appstream Fleet (%s) sessions in use (%d) to drop to %d", d.Id(), in_use, desired_instances)
+		}
 
+		log.Printf("[DEBUG] Appstream Fleet (%s) has %d sessions in use, waiting before scaling down to %d", d.Id(), in_use, desired_instances)
+		time.Sleep(20 * time.Second)
+	}
 }
 
-func resourceAppstreamFleetDelete(d *schema.ResourceData, meta interface{}) error {
+// suppressExternallyManagedCapacity hides desired_instances drift once the fleet exists
+// and its capacity is driven by Application Auto Scaling instead of this resource.
+func suppressExternallyManagedCapacity(k, old, new string, d *schema.ResourceData) bool {
+	return d.Id() != "" && d.Get("capacity_managed_externally").(bool)
+}
 
-	svc := meta.(*AWSClient).appstreamconn
+// waitForAppstreamFleetState polls the fleet until it reaches state or the timeout expires.
+func waitForAppstreamFleetState(svc *appstream.AppStream, name string, state string, timeout time.Duration) (*appstream.Fleet, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		fleet, err := describeFleet(svc, name)
+		if err != nil {
+			return nil, err
+		}
+		if fleet == nil {
+			return nil, fmt.Errorf("Appstream Fleet (%s) not found while waiting for state %s", name, state)
+		}
+		if aws.StringValue(fleet.State) == state {
+			return fleet, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timeout waiting for Appstream Fleet (%s) to reach state %s, last state %s", name, state, aws.StringValue(fleet.State))
+		}
+		log.Printf("[DEBUG] Appstream Fleet (%s) is %s, waiting for %s", name, aws.StringValue(fleet.State), state)
+		time.Sleep(20 * time.Second)
+	}
+}
 
-    resp, err := svc.DescribeFleets(&appstream.DescribeFleetsInput{
-	    Names: aws.StringSlice([]string{*aws.String(d.Id())}),
-    })
+// waitForAppstreamFleetCapacityConfig waits for the capacity asked for in wait_for_capacity, if any.
+func waitForAppstreamFleetCapacityConfig(d *schema.ResourceData, svc *appstream.AppStream) error {
+	v, ok := d.GetOk("wait_for_capacity")
+	if !ok || len(v.([]interface{})) == 0 || v.([]interface{})[0] == nil {
+		return nil
+	}
+	attr := v.([]interface{})[0].(map[string]interface{})
 
-    if err != nil {
-	log.Printf("[ERROR] Error reading Appstream Fleet: %s", err)
-	return err
-    }
-
-    curr_state := aws.StringValue(resp.Fleets[0].State)
-
-    if  curr_state == "RUNNING" {
-	    desired_state := "STOPPED"
-	    svc.StopFleet(&appstream.StopFleetInput{
-		    Name: aws.String(d.Id()),
-	    })
-	    for {
-
-		    resp, err := svc.DescribeFleets(&appstream.DescribeFleetsInput{
-			Names:  aws.StringSlice([]string{*aws.String(d.Id())}),
-		    })
-		    if err != nil {
-			log.Printf("[ERROR] Error describing Appstream Fleet: %s", err)
+	timeout, err := time.ParseDuration(attr["timeout"].(string))
+	if err != nil {
+		return fmt.Errorf("invalid wait_for_capacity timeout: %s", err)
+	}
+	return waitForAppstreamFleetCapacity(svc, d.Id(), int64(attr["min_available"].(int)), timeout)
+}
+
+// waitForAppstreamFleetCapacity polls the fleet until at least minAvailable instances are
+// available, or its desired capacity when minAvailable is 0. RUNNING only means the fleet
+// accepted the start, instances can take a long time more to become available.
+func waitForAppstreamFleetCapacity(svc *appstream.AppStream, name string, minAvailable int64, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		fleet, err := describeFleet(svc, name)
+		if err != nil {
 			return err
-		    }
+		}
+		if fleet == nil {
+			return fmt.Errorf("Appstream Fleet (%s) not found while waiting for capacity", name)
+		}
+
+		// Only a running or starting fleet gets instances, anything else would wait until
+		// the timeout for capacity that is not coming.
+		switch state := aws.StringValue(fleet.State); state {
+		case appstream.FleetStateRunning, appstream.FleetStateStarting:
+		default:
+			return fmt.Errorf("Appstream Fleet (%s) is %s and gets no capacity, set desired_state to RUNNING to wait for it", name, state)
+		}
+
+		var available, desired int64
+		if fleet.ComputeCapacityStatus != nil {
+			available = aws.Int64Value(fleet.ComputeCapacityStatus.Available)
+			desired = aws.Int64Value(fleet.ComputeCapacityStatus.Desired)
+		}
+		want := minAvailable
+		if want == 0 {
+			want = desired
+		}
+		if available >= want {
+			return nil
+		}
 
-		    curr_state := resp.Fleets[0].State
-		    if aws.StringValue(curr_state) == desired_state{
-			break
-		    }
-		    if aws.StringValue(curr_state) != desired_state {
-			time.Sleep(20 * time.Second)
-			continue
-		    }
+		if time.Now().After(deadline) {
+			errs := make([]string, 0, len(fleet.FleetErrors))
+			for _, e := range fleet.FleetErrors {
+				errs = append(errs, fmt.Sprintf("%s: %s", aws.StringValue(e.ErrorCode), aws.StringValue(e.ErrorMessage)))
+			}
+			if len(errs) == 0 {
+				errs = append(errs, "no fleet errors reported")
+			}
+			return fmt.Errorf("timeout waiting for Appstream Fleet (%s) capacity, %d of %d instances available: %s",
+				name, available, want, strings.Join(errs, "; "))
+		}
+		for _, e := range fleet.FleetErrors {
+			log.Printf("[WARN] Appstream Fleet (%s) error %s: %s", name, aws.StringValue(e.ErrorCode), aws.StringValue(e.ErrorMessage))
+		}
+		log.Printf("[DEBUG] Appstream Fleet (%s) has %d of %d instances available, waiting", name, available, want)
+		time.Sleep(20 * time.Second)
+	}
+}
 
-	    }
+// waitForAppstreamFleetStable waits for a fleet in STARTING or STOPPING to settle.
+func waitForAppstreamFleetStable(svc *appstream.AppStream, name string, timeout time.Duration) (*appstream.Fleet, error) {
+	fleet, err := describeFleet(svc, name)
+	if err != nil {
+		return nil, err
+	}
+	if fleet == nil {
+		return nil, fmt.Errorf("Appstream Fleet (%s) not found", name)
+	}
 
-    }
+	switch aws.StringValue(fleet.State) {
+	case appstream.FleetStateStarting:
+		return waitForAppstreamFleetState(svc, name, appstream.FleetStateRunning, timeout)
+	case appstream.FleetStateStopping:
+		return waitForAppstreamFleetState(svc, name, appstream.FleetStateStopped, timeout)
+	}
+	return fleet, nil
+}
 
+// stableFleetState returns the observed state when the fleet is RUNNING or STOPPED and
+// desired otherwise, so a fleet caught mid-transition is not reported as drift.
+func stableFleetState(observed string, desired string) string {
+	switch observed {
+	case appstream.FleetStateRunning, appstream.FleetStateStopped:
+		return observed
+	}
+	return desired
+}
 
+// reconcileAppstreamFleetState lets a transition in progress finish, then starts or stops
+// the fleet if it did not end up in the desired state.
+func reconcileAppstreamFleetState(svc *appstream.AppStream, name string, desired string, timeout time.Duration) error {
+	fleet, err := waitForAppstreamFleetStable(svc, name, timeout)
+	if err != nil {
+		return err
+	}
+	if aws.StringValue(fleet.State) == desired {
+		return nil
+	}
 
-    dis, err := svc.DisassociateFleet(&appstream.DisassociateFleetInput{
-	    FleetName: aws.String(d.Id()),
-	    StackName: aws.String(d.Get("stack_name").(string)),
-    })
-    if err != nil {
-        log.Printf("[ERROR] Error deleting Appstream Fleet: %s", err)
-	return err
-    }
-    log.Printf("[DEBUG] %s", dis)
-
-    del, err := svc.DeleteFleet(&appstream.DeleteFleetInput{
-        Name:   aws.String(d.Id()),
-    })
-    if err != nil {
-        log.Printf("[ERROR] Error deleting Appstream Fleet: %s", err)
+	switch desired {
+	case appstream.FleetStateRunning:
+		resp, err := svc.StartFleet(&appstream.StartFleetInput{
+			Name: aws.String(name),
+		})
+		if err != nil {
+			log.Printf("[ERROR] Error starting Appstream Fleet: %s", err)
+			return err
+		}
+		log.Printf("[DEBUG] %s", resp)
+	case appstream.FleetStateStopped:
+		resp, err := svc.StopFleet(&appstream.StopFleetInput{
+			Name: aws.String(name),
+		})
+		if err != nil {
+			log.Printf("[ERROR] Error stopping Appstream Fleet: %s", err)
+			return err
+		}
+		log.Printf("[DEBUG] %s", resp)
+	}
+
+	_, err = waitForAppstreamFleetState(svc, name, desired, timeout)
 	return err
-    }
-    log.Printf("[DEBUG] %s", del)
-    return nil
+}
 
+// listAssociatedStacks returns the names of every stack the fleet is associated with.
+func listAssociatedStacks(svc *appstream.AppStream, fleetName string) ([]string, error) {
+	stacks := make([]string, 0)
+	input := &appstream.ListAssociatedStacksInput{
+		FleetName: aws.String(fleetName),
+	}
+	for {
+		resp, err := svc.ListAssociatedStacks(input)
+		if err != nil {
+			log.Printf("[ERROR] Error listing stacks associated with Appstream Fleet: %s", err)
+			return nil, err
+		}
+		stacks = append(stacks, aws.StringValueSlice(resp.Names)...)
+		if aws.StringValue(resp.NextToken) == "" {
+			return stacks, nil
+		}
+		input.NextToken = resp.NextToken
+	}
+}
+
+// describeFleet returns the named fleet, or nil when it does not exist.
+func describeFleet(svc *appstream.AppStream, name string) (*appstream.Fleet, error) {
+	resp, err := svc.DescribeFleets(&appstream.DescribeFleetsInput{
+		Names: aws.StringSlice([]string{name}),
+	})
+	if isAWSErr(err, appstream.ErrCodeResourceNotFoundException, "") {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("[ERROR] Error describing Appstream Fleet: %s", err)
+		return nil, err
+	}
+	if len(resp.Fleets) == 0 {
+		return nil, nil
+	}
+	return resp.Fleets[0], nil
 }
@@ -0,0 +1,161 @@
+package appstream
+
+import (
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+// resourceAppstreamFleetV0 is the appstream_fleet schema before vpc_config held sets of IDs
+// and state was split into desired_state and a computed state.
+func resourceAppstreamFleetV0() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"compute_capacity": {
+				Type:     schema.TypeList,
+				Required: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"desired_instances": {
+							Type:     schema.TypeInt,
+							Required: true,
+						},
+					},
+				},
+			},
+			"description": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"disconnect_timeout": {
+				Type:     schema.TypeInt,
+				Optional: true,
+			},
+			"display_name": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"domain_info": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"directory_name": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"organizational_unit_distinguished_name": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+					},
+				},
+			},
+			"enable_default_internet_access": {
+				Type:     schema.TypeBool,
+				Optional: true,
+			},
+			"fleet_type": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"image_arn": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"iam_role_arn": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"instance_type": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"max_user_duration": {
+				Type:     schema.TypeInt,
+				Optional: true,
+			},
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"stack_name": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"state": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"vpc_config": vpcConfigSchemaV0(),
+			"tags": {
+				Type:     schema.TypeMap,
+				Optional: true,
+			},
+		},
+	}
+}
+
+func resourceAppstreamFleetStateUpgradeV0(rawState map[string]interface{}, meta interface{}) (map[string]interface{}, error) {
+	upgradeVpcConfigV0(rawState)
+
+	// Arguments added since version 0 start at their defaults instead of being planned in.
+	for k, v := range map[string]interface{}{
+		"capacity_managed_externally": false,
+		"deletion_protection":         false,
+		"scale_down_protection":       scaleDownProtectionDisabled,
+	} {
+		if _, ok := rawState[k]; !ok {
+			rawState[k] = v
+		}
+	}
+	return rawState, nil
+}
+
+// vpcConfigSchemaV0 is vpc_config as it was when security groups and subnets were comma-joined strings.
+func vpcConfigSchemaV0() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeList,
+		Optional: true,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"security_group_ids": {
+					Type:     schema.TypeString,
+					Optional: true,
+				},
+				"subnet_ids": {
+					Type:     schema.TypeString,
+					Optional: true,
+				},
+			},
+		},
+	}
+}
+
+// upgradeVpcConfigV0 splits the comma-joined vpc_config IDs of a version 0 state into lists.
+func upgradeVpcConfigV0(rawState map[string]interface{}) {
+	vpcConfigs, ok := rawState["vpc_config"].([]interface{})
+	if !ok {
+		return
+	}
+
+	for _, raw := range vpcConfigs {
+		vpcConfig, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, k := range []string{"security_group_ids", "subnet_ids"} {
+			ids := make([]interface{}, 0)
+			if v, ok := vpcConfig[k].(string); ok {
+				for _, id := range strings.Split(v, ",") {
+					if id = strings.TrimSpace(id); id != "" {
+						ids = append(ids, id)
+					}
+				}
+			}
+			vpcConfig[k] = ids
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package appstream
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/applicationautoscaling"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+func resourceAppstreamFleetScalableTarget() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceAppstreamFleetScalableTargetPut,
+		Read:   resourceAppstreamFleetScalableTargetRead,
+		Update: resourceAppstreamFleetScalableTargetPut,
+		Delete: resourceAppstreamFleetScalableTargetDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceAppstreamFleetScalableTargetImport,
+		},
+
+		CustomizeDiff: customizeDiffCapacityBounds("min_capacity", "max_capacity"),
+
+		Schema: map[string]*schema.Schema{
+			"fleet_name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"max_capacity": {
+				Type:         schema.TypeInt,
+				Required:     true,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+
+			"min_capacity": {
+				Type:         schema.TypeInt,
+				Required:     true,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+
+			"resource_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"role_arn": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+
+			"scalable_dimension": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+// customizeDiffCapacityBounds rejects a min capacity above the max capacity at plan time
+// instead of leaving it to Application Auto Scaling during apply.
+func customizeDiffCapacityBounds(minKey, maxKey string) schema.CustomizeDiffFunc {
+	return func(diff *schema.ResourceDiff, meta interface{}) error {
+		if !diff.NewValueKnown(minKey) || !diff.NewValueKnown(maxKey) {
+			return nil
+		}
+		min := diff.Get(minKey).(int)
+		max := diff.Get(maxKey).(int)
+		if min > max {
+			return fmt.Errorf("%s (%d) must not exceed %s (%d)", minKey, min, maxKey, max)
+		}
+		return nil
+	}
+}
+
+// appstreamFleetResourceID returns the Application Auto Scaling resource ID of a fleet.
+func appstreamFleetResourceID(fleetName string) string {
+	return fmt.Sprintf("fleet/%s", fleetName)
+}
+
+func resourceAppstreamFleetScalableTargetPut(d *schema.ResourceData, meta interface{}) error {
+	svc := meta.(*AWSClient).applicationautoscalingconn
+
+	fleetName := d.Get("fleet_name").(string)
+	RegisterScalableTargetInputOpts := &applicationautoscaling.RegisterScalableTargetInput{
+		MaxCapacity:       aws.Int64(int64(d.Get("max_capacity").(int))),
+		MinCapacity:       aws.Int64(int64(d.Get("min_capacity").(int))),
+		ResourceId:        aws.String(appstreamFleetResourceID(fleetName)),
+		ScalableDimension: aws.String(applicationautoscaling.ScalableDimensionAppstreamFleetDesiredCapacity),
+		ServiceNamespace:  aws.String(applicationautoscaling.ServiceNamespaceAppstream),
+	}
+
+	if v, ok := d.GetOk("role_arn"); ok {
+		RegisterScalableTargetInputOpts.RoleARN = aws.String(v.(string))
+	}
+
+	log.Printf("[DEBUG] Run configuration: %s", RegisterScalableTargetInputOpts)
+	resp, err := svc.RegisterScalableTarget(RegisterScalableTargetInputOpts)
+	if err != nil {
+		log.Printf("[ERROR] Error registering Appstream Fleet scalable target: %s", err)
+		return err
+	}
+	log.Printf("[DEBUG] %s", resp)
+
+	d.SetId(fleetName)
+
+	return resourceAppstreamFleetScalableTargetRead(d, meta)
+}
+
+func resourceAppstreamFleetScalableTargetRead(d *schema.ResourceData, meta interface{}) error {
+	svc := meta.(*AWSClient).applicationautoscalingconn
+
+	target, err := describeAppstreamFleetScalableTarget(svc, d.Id())
+	if err != nil {
+		return err
+	}
+	if target == nil {
+		log.Printf("[WARN] Appstream Fleet scalable target (%s) not found, removing from state", d.Id())
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("fleet_name", d.Id())
+	d.Set("max_capacity", target.MaxCapacity)
+	d.Set("min_capacity", target.MinCapacity)
+	d.Set("resource_id", target.ResourceId)
+	d.Set("role_arn", target.RoleARN)
+	d.Set("scalable_dimension", target.ScalableDimension)
+
+	return nil
+}
+
+func resourceAppstreamFleetScalableTargetDelete(d *schema.ResourceData, meta interface{}) error {
+	svc := meta.(*AWSClient).applicationautoscalingconn
+
+	resp, err := svc.DeregisterScalableTarget(&applicationautoscaling.DeregisterScalableTargetInput{
+		ResourceId:        aws.String(appstreamFleetResourceID(d.Id())),
+		ScalableDimension: aws.String(applicationautoscaling.ScalableDimensionAppstreamFleetDesiredCapacity),
+		ServiceNamespace:  aws.String(applicationautoscaling.ServiceNamespaceAppstream),
+	})
+	if isAWSErr(err, applicationautoscaling.ErrCodeObjectNotFoundException, "") {
+		return nil
+	}
+	if err != nil {
+		log.Printf("[ERROR] Error deregistering Appstream Fleet scalable target: %s", err)
+		return err
+	}
+	log.Printf("[DEBUG] %s", resp)
+
+	return nil
+}
+
+// resourceAppstreamFleetScalableTargetImport accepts either the fleet name or its fleet/<name> resource ID.
+func resourceAppstreamFleetScalableTargetImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	d.SetId(strings.TrimPrefix(d.Id(), "fleet/"))
+	return []*schema.ResourceData{d}, nil
+}
+
+// describeAppstreamFleetScalableTarget returns the scalable target of the named fleet, or nil when none is registered.
+func describeAppstreamFleetScalableTarget(svc *applicationautoscaling.ApplicationAutoScaling, fleetName string) (*applicationautoscaling.ScalableTarget, error) {
+	resp, err := svc.DescribeScalableTargets(&applicationautoscaling.DescribeScalableTargetsInput{
+		ResourceIds:       aws.StringSlice([]string{appstreamFleetResourceID(fleetName)}),
+		ScalableDimension: aws.String(applicationautoscaling.ScalableDimensionAppstreamFleetDesiredCapacity),
+		ServiceNamespace:  aws.String(applicationautoscaling.ServiceNamespaceAppstream),
+	})
+	if err != nil {
+		log.Printf("[ERROR] Error describing Appstream Fleet scalable target: %s", err)
+		return nil, err
+	}
+
+	for _, target := range resp.ScalableTargets {
+		if aws.StringValue(target.ResourceId) == appstreamFleetResourceID(fleetName) {
+			return target, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,57 @@
+package appstream
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+)
+
+func TestResourceAppstreamFleetScalableTarget_replacement(t *testing.T) {
+	testCheckSchemaReplacement(t, resourceAppstreamFleetScalableTarget(),
+		[]string{
+			"fleet_name",
+		},
+		[]string{
+			"max_capacity",
+			"min_capacity",
+			"role_arn",
+		},
+	)
+}
+
+func TestResourceAppstreamFleetScalableTarget_capacityBounds(t *testing.T) {
+	cases := map[string]struct {
+		min, max  int
+		expectErr bool
+	}{
+		"below max": {1, 10, false},
+		"equal":     {5, 5, false},
+		"above max": {10, 1, true},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := resourceAppstreamFleetScalableTarget().Diff(nil, terraform.NewResourceConfigRaw(map[string]interface{}{
+				"fleet_name":   "test-fleet",
+				"min_capacity": tc.min,
+				"max_capacity": tc.max,
+			}), nil)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error %t, got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestResourceAppstreamFleetScalableTargetImport(t *testing.T) {
+	for _, id := range []string{"test-fleet", "fleet/test-fleet"} {
+		d := resourceAppstreamFleetScalableTarget().Data(&terraform.InstanceState{ID: id})
+		result, err := resourceAppstreamFleetScalableTargetImport(d, nil)
+		if err != nil {
+			t.Fatalf("error importing %s: %s", id, err)
+		}
+		if got := result[0].Id(); got != "test-fleet" {
+			t.Errorf("expected ID test-fleet for %s, got %s", id, got)
+		}
+	}
+}
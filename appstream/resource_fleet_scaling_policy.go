@@ -0,0 +1,230 @@
+package appstream
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/applicationautoscaling"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+func resourceAppstreamFleetScalingPolicy() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceAppstreamFleetScalingPolicyPut,
+		Read:   resourceAppstreamFleetScalingPolicyRead,
+		Update: resourceAppstreamFleetScalingPolicyPut,
+		Delete: resourceAppstreamFleetScalingPolicyDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"arn": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"fleet_name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"policy_type": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"resource_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"target_tracking_scaling_policy_configuration": {
+				Type:     schema.TypeList,
+				Required: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"disable_scale_in": {
+							Type:     schema.TypeBool,
+							Optional: true,
+						},
+						"predefined_metric_type": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      applicationautoscaling.MetricTypeAppStreamAverageCapacityUtilization,
+							ValidateFunc: validation.StringInSlice([]string{applicationautoscaling.MetricTypeAppStreamAverageCapacityUtilization}, false),
+						},
+						"scale_in_cooldown": {
+							Type:         schema.TypeInt,
+							Optional:     true,
+							ValidateFunc: validation.IntAtLeast(0),
+						},
+						"scale_out_cooldown": {
+							Type:         schema.TypeInt,
+							Optional:     true,
+							ValidateFunc: validation.IntAtLeast(0),
+						},
+						"target_value": {
+							Type:         schema.TypeFloat,
+							Required:     true,
+							ValidateFunc: validation.FloatBetween(0, 100),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func resourceAppstreamFleetScalingPolicyPut(d *schema.ResourceData, meta interface{}) error {
+	svc := meta.(*AWSClient).applicationautoscalingconn
+
+	fleetName := d.Get("fleet_name").(string)
+	name := d.Get("name").(string)
+	PutScalingPolicyInputOpts := &applicationautoscaling.PutScalingPolicyInput{
+		PolicyName:        aws.String(name),
+		PolicyType:        aws.String(applicationautoscaling.PolicyTypeTargetTrackingScaling),
+		ResourceId:        aws.String(appstreamFleetResourceID(fleetName)),
+		ScalableDimension: aws.String(applicationautoscaling.ScalableDimensionAppstreamFleetDesiredCapacity),
+		ServiceNamespace:  aws.String(applicationautoscaling.ServiceNamespaceAppstream),
+		TargetTrackingScalingPolicyConfiguration: expandTargetTrackingScalingPolicyConfiguration(
+			d.Get("target_tracking_scaling_policy_configuration").([]interface{})),
+	}
+
+	log.Printf("[DEBUG] Run configuration: %s", PutScalingPolicyInputOpts)
+	resp, err := svc.PutScalingPolicy(PutScalingPolicyInputOpts)
+	if err != nil {
+		log.Printf("[ERROR] Error putting Appstream Fleet scaling policy: %s", err)
+		return err
+	}
+	log.Printf("[DEBUG] %s", resp)
+
+	d.SetId(fmt.Sprintf("%s/%s", fleetName, name))
+
+	return resourceAppstreamFleetScalingPolicyRead(d, meta)
+}
+
+func resourceAppstreamFleetScalingPolicyRead(d *schema.ResourceData, meta interface{}) error {
+	svc := meta.(*AWSClient).applicationautoscalingconn
+
+	fleetName, name, err := parseAppstreamFleetScopedID(d.Id())
+	if err != nil {
+		return err
+	}
+
+	resp, err := svc.DescribeScalingPolicies(&applicationautoscaling.DescribeScalingPoliciesInput{
+		PolicyNames:       aws.StringSlice([]string{name}),
+		ResourceId:        aws.String(appstreamFleetResourceID(fleetName)),
+		ScalableDimension: aws.String(applicationautoscaling.ScalableDimensionAppstreamFleetDesiredCapacity),
+		ServiceNamespace:  aws.String(applicationautoscaling.ServiceNamespaceAppstream),
+	})
+	if err != nil {
+		log.Printf("[ERROR] Error describing Appstream Fleet scaling policy: %s", err)
+		return err
+	}
+
+	for _, v := range resp.ScalingPolicies {
+		if aws.StringValue(v.PolicyName) != name {
+			continue
+		}
+
+		d.Set("arn", v.PolicyARN)
+		d.Set("fleet_name", fleetName)
+		d.Set("name", v.PolicyName)
+		d.Set("policy_type", v.PolicyType)
+		d.Set("resource_id", v.ResourceId)
+		if err := d.Set("target_tracking_scaling_policy_configuration", flattenTargetTrackingScalingPolicyConfiguration(v.TargetTrackingScalingPolicyConfiguration)); err != nil {
+			log.Printf("[ERROR] Error setting target tracking configuration: %s", err)
+			return err
+		}
+		return nil
+	}
+
+	log.Printf("[WARN] Appstream Fleet scaling policy (%s) not found, removing from state", d.Id())
+	d.SetId("")
+	return nil
+}
+
+func resourceAppstreamFleetScalingPolicyDelete(d *schema.ResourceData, meta interface{}) error {
+	svc := meta.(*AWSClient).applicationautoscalingconn
+
+	fleetName, name, err := parseAppstreamFleetScopedID(d.Id())
+	if err != nil {
+		return err
+	}
+
+	resp, err := svc.DeleteScalingPolicy(&applicationautoscaling.DeleteScalingPolicyInput{
+		PolicyName:        aws.String(name),
+		ResourceId:        aws.String(appstreamFleetResourceID(fleetName)),
+		ScalableDimension: aws.String(applicationautoscaling.ScalableDimensionAppstreamFleetDesiredCapacity),
+		ServiceNamespace:  aws.String(applicationautoscaling.ServiceNamespaceAppstream),
+	})
+	if isAWSErr(err, applicationautoscaling.ErrCodeObjectNotFoundException, "") {
+		return nil
+	}
+	if err != nil {
+		log.Printf("[ERROR] Error deleting Appstream Fleet scaling policy: %s", err)
+		return err
+	}
+	log.Printf("[DEBUG] %s", resp)
+
+	return nil
+}
+
+// parseAppstreamFleetScopedID splits a <fleet-name>/<name> ID of an object that belongs to a fleet.
+func parseAppstreamFleetScopedID(id string) (string, string, error) {
+	parts := strings.SplitN(id, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("unexpected format of ID (%s), expected fleet-name/name", id)
+	}
+	return parts[0], parts[1], nil
+}
+
+func expandTargetTrackingScalingPolicyConfiguration(configs []interface{}) *applicationautoscaling.TargetTrackingScalingPolicyConfiguration {
+	if len(configs) == 0 || configs[0] == nil {
+		return nil
+	}
+
+	attr := configs[0].(map[string]interface{})
+	config := &applicationautoscaling.TargetTrackingScalingPolicyConfiguration{
+		DisableScaleIn: aws.Bool(attr["disable_scale_in"].(bool)),
+		PredefinedMetricSpecification: &applicationautoscaling.PredefinedMetricSpecification{
+			PredefinedMetricType: aws.String(attr["predefined_metric_type"].(string)),
+		},
+		TargetValue: aws.Float64(attr["target_value"].(float64)),
+	}
+	if v := attr["scale_in_cooldown"].(int); v > 0 {
+		config.ScaleInCooldown = aws.Int64(int64(v))
+	}
+	if v := attr["scale_out_cooldown"].(int); v > 0 {
+		config.ScaleOutCooldown = aws.Int64(int64(v))
+	}
+	return config
+}
+
+func flattenTargetTrackingScalingPolicyConfiguration(config *applicationautoscaling.TargetTrackingScalingPolicyConfiguration) []interface{} {
+	if config == nil {
+		return nil
+	}
+
+	attr := map[string]interface{}{}
+	attr["disable_scale_in"] = aws.BoolValue(config.DisableScaleIn)
+	if config.PredefinedMetricSpecification != nil {
+		attr["predefined_metric_type"] = aws.StringValue(config.PredefinedMetricSpecification.PredefinedMetricType)
+	}
+	attr["scale_in_cooldown"] = int(aws.Int64Value(config.ScaleInCooldown))
+	attr["scale_out_cooldown"] = int(aws.Int64Value(config.ScaleOutCooldown))
+	attr["target_value"] = aws.Float64Value(config.TargetValue)
+	return []interface{}{attr}
+}
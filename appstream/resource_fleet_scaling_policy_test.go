@@ -0,0 +1,63 @@
+package appstream
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/applicationautoscaling"
+)
+
+func TestResourceAppstreamFleetScalingPolicy_replacement(t *testing.T) {
+	testCheckSchemaReplacement(t, resourceAppstreamFleetScalingPolicy(),
+		[]string{
+			"fleet_name",
+			"name",
+		},
+		[]string{
+			"target_tracking_scaling_policy_configuration",
+		},
+	)
+}
+
+func TestParseAppstreamFleetScopedID(t *testing.T) {
+	cases := map[string]struct {
+		id, fleetName, name string
+		expectErr           bool
+	}{
+		"valid":             {"test-fleet/utilization", "test-fleet", "utilization", false},
+		"slash in the name": {"test-fleet/scale/out", "test-fleet", "scale/out", false},
+		"fleet only":        {"test-fleet", "", "", true},
+		"empty fleet":       {"/utilization", "", "", true},
+		"empty name":        {"test-fleet/", "", "", true},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			fleetName, n, err := parseAppstreamFleetScopedID(tc.id)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error %t, got %v", tc.expectErr, err)
+			}
+			if fleetName != tc.fleetName || n != tc.name {
+				t.Errorf("expected %q and %q, got %q and %q", tc.fleetName, tc.name, fleetName, n)
+			}
+		})
+	}
+}
+
+func TestTargetTrackingScalingPolicyConfiguration(t *testing.T) {
+	config := []interface{}{map[string]interface{}{
+		"disable_scale_in":       true,
+		"predefined_metric_type": applicationautoscaling.MetricTypeAppStreamAverageCapacityUtilization,
+		"scale_in_cooldown":      600,
+		"scale_out_cooldown":     0,
+		"target_value":           75.0,
+	}}
+
+	expanded := expandTargetTrackingScalingPolicyConfiguration(config)
+	if expanded.ScaleOutCooldown != nil {
+		t.Errorf("expected an unset scale_out_cooldown to be left out, got %d", *expanded.ScaleOutCooldown)
+	}
+	if got := flattenTargetTrackingScalingPolicyConfiguration(expanded); !reflect.DeepEqual(got, config) {
+		t.Errorf("expected %v after a round trip, got %v", config, got)
+	}
+}
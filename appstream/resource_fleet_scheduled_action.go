@@ -0,0 +1,235 @@
+package appstream
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/applicationautoscaling"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+// resourceAppstreamFleetScheduledAction changes the capacity bounds of a fleet's scalable
+// target on a schedule. Fleets driven this way should set capacity_managed_externally so
+// the capacity the action applies does not show up as drift on appstream_fleet.
+func resourceAppstreamFleetScheduledAction() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceAppstreamFleetScheduledActionPut,
+		Read:   resourceAppstreamFleetScheduledActionRead,
+		Update: resourceAppstreamFleetScheduledActionPut,
+		Delete: resourceAppstreamFleetScheduledActionDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		CustomizeDiff: customizeDiffCapacityBounds("scalable_target_action.0.min_capacity", "scalable_target_action.0.max_capacity"),
+
+		Schema: map[string]*schema.Schema{
+			"arn": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"end_time": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				ValidateFunc:     validation.IsRFC3339Time,
+				DiffSuppressFunc: suppressEquivalentTime,
+			},
+
+			"fleet_name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"scalable_target_action": {
+				Type:     schema.TypeList,
+				Required: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"max_capacity": {
+							Type:         schema.TypeInt,
+							Required:     true,
+							ValidateFunc: validation.IntAtLeast(0),
+						},
+						"min_capacity": {
+							Type:         schema.TypeInt,
+							Required:     true,
+							ValidateFunc: validation.IntAtLeast(0),
+						},
+					},
+				},
+			},
+
+			"schedule": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validateAppautoscalingScheduleExpression,
+			},
+
+			"start_time": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				ValidateFunc:     validation.IsRFC3339Time,
+				DiffSuppressFunc: suppressEquivalentTime,
+			},
+
+			"timezone": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "UTC",
+				ValidateFunc: validateTimezone,
+			},
+		},
+	}
+}
+
+func resourceAppstreamFleetScheduledActionPut(d *schema.ResourceData, meta interface{}) error {
+	svc := meta.(*AWSClient).applicationautoscalingconn
+
+	fleetName := d.Get("fleet_name").(string)
+	name := d.Get("name").(string)
+	PutScheduledActionInputOpts := &applicationautoscaling.PutScheduledActionInput{
+		ResourceId:          aws.String(appstreamFleetResourceID(fleetName)),
+		ScalableDimension:   aws.String(applicationautoscaling.ScalableDimensionAppstreamFleetDesiredCapacity),
+		Schedule:            aws.String(d.Get("schedule").(string)),
+		ScheduledActionName: aws.String(name),
+		ServiceNamespace:    aws.String(applicationautoscaling.ServiceNamespaceAppstream),
+		Timezone:            aws.String(d.Get("timezone").(string)),
+	}
+
+	PutScheduledActionInputOpts.ScalableTargetAction = &applicationautoscaling.ScalableTargetAction{
+		MaxCapacity: aws.Int64(int64(d.Get("scalable_target_action.0.max_capacity").(int))),
+		MinCapacity: aws.Int64(int64(d.Get("scalable_target_action.0.min_capacity").(int))),
+	}
+
+	if v, ok := d.GetOk("start_time"); ok {
+		t, _ := time.Parse(time.RFC3339, v.(string))
+		PutScheduledActionInputOpts.StartTime = aws.Time(t)
+	}
+
+	if v, ok := d.GetOk("end_time"); ok {
+		t, _ := time.Parse(time.RFC3339, v.(string))
+		PutScheduledActionInputOpts.EndTime = aws.Time(t)
+	}
+
+	log.Printf("[DEBUG] Run configuration: %s", PutScheduledActionInputOpts)
+	resp, err := svc.PutScheduledAction(PutScheduledActionInputOpts)
+	if err != nil {
+		log.Printf("[ERROR] Error putting Appstream Fleet scheduled action: %s", err)
+		return err
+	}
+	log.Printf("[DEBUG] %s", resp)
+
+	d.SetId(fmt.Sprintf("%s/%s", fleetName, name))
+
+	return resourceAppstreamFleetScheduledActionRead(d, meta)
+}
+
+func resourceAppstreamFleetScheduledActionRead(d *schema.ResourceData, meta interface{}) error {
+	svc := meta.(*AWSClient).applicationautoscalingconn
+
+	fleetName, name, err := parseAppstreamFleetScopedID(d.Id())
+	if err != nil {
+		return err
+	}
+
+	resp, err := svc.DescribeScheduledActions(&applicationautoscaling.DescribeScheduledActionsInput{
+		ResourceId:           aws.String(appstreamFleetResourceID(fleetName)),
+		ScalableDimension:    aws.String(applicationautoscaling.ScalableDimensionAppstreamFleetDesiredCapacity),
+		ScheduledActionNames: aws.StringSlice([]string{name}),
+		ServiceNamespace:     aws.String(applicationautoscaling.ServiceNamespaceAppstream),
+	})
+	if err != nil {
+		log.Printf("[ERROR] Error describing Appstream Fleet scheduled action: %s", err)
+		return err
+	}
+
+	for _, v := range resp.ScheduledActions {
+		if aws.StringValue(v.ScheduledActionName) != name {
+			continue
+		}
+
+		d.Set("arn", v.ScheduledActionARN)
+		d.Set("fleet_name", fleetName)
+		d.Set("name", v.ScheduledActionName)
+		d.Set("schedule", v.Schedule)
+		d.Set("timezone", v.Timezone)
+
+		if v.StartTime != nil {
+			d.Set("start_time", aws.TimeValue(v.StartTime).Format(time.RFC3339))
+		} else {
+			d.Set("start_time", "")
+		}
+		if v.EndTime != nil {
+			d.Set("end_time", aws.TimeValue(v.EndTime).Format(time.RFC3339))
+		} else {
+			d.Set("end_time", "")
+		}
+
+		action_attr := map[string]interface{}{}
+		if v.ScalableTargetAction != nil {
+			action_attr["max_capacity"] = int(aws.Int64Value(v.ScalableTargetAction.MaxCapacity))
+			action_attr["min_capacity"] = int(aws.Int64Value(v.ScalableTargetAction.MinCapacity))
+		}
+		if err := d.Set("scalable_target_action", []interface{}{action_attr}); err != nil {
+			log.Printf("[ERROR] Error setting scalable target action: %s", err)
+			return err
+		}
+		return nil
+	}
+
+	log.Printf("[WARN] Appstream Fleet scheduled action (%s) not found, removing from state", d.Id())
+	d.SetId("")
+	return nil
+}
+
+func resourceAppstreamFleetScheduledActionDelete(d *schema.ResourceData, meta interface{}) error {
+	svc := meta.(*AWSClient).applicationautoscalingconn
+
+	fleetName, name, err := parseAppstreamFleetScopedID(d.Id())
+	if err != nil {
+		return err
+	}
+
+	resp, err := svc.DeleteScheduledAction(&applicationautoscaling.DeleteScheduledActionInput{
+		ResourceId:          aws.String(appstreamFleetResourceID(fleetName)),
+		ScalableDimension:   aws.String(applicationautoscaling.ScalableDimensionAppstreamFleetDesiredCapacity),
+		ScheduledActionName: aws.String(name),
+		ServiceNamespace:    aws.String(applicationautoscaling.ServiceNamespaceAppstream),
+	})
+	if isAWSErr(err, applicationautoscaling.ErrCodeObjectNotFoundException, "") {
+		return nil
+	}
+	if err != nil {
+		log.Printf("[ERROR] Error deleting Appstream Fleet scheduled action: %s", err)
+		return err
+	}
+	log.Printf("[DEBUG] %s", resp)
+
+	return nil
+}
+
+// suppressEquivalentTime hides differences between RFC 3339 timestamps of the same instant,
+// the API returns them in UTC whatever offset was configured.
+func suppressEquivalentTime(k, old, new string, d *schema.ResourceData) bool {
+	o, err := time.Parse(time.RFC3339, old)
+	if err != nil {
+		return false
+	}
+	n, err := time.Parse(time.RFC3339, new)
+	if err != nil {
+		return false
+	}
+	return o.Equal(n)
+}
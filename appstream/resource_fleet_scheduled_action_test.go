@@ -0,0 +1,70 @@
+package appstream
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+)
+
+func TestResourceAppstreamFleetScheduledAction_replacement(t *testing.T) {
+	testCheckSchemaReplacement(t, resourceAppstreamFleetScheduledAction(),
+		[]string{
+			"fleet_name",
+			"name",
+		},
+		[]string{
+			"end_time",
+			"scalable_target_action",
+			"schedule",
+			"start_time",
+			"timezone",
+		},
+	)
+}
+
+func TestResourceAppstreamFleetScheduledAction_capacityBounds(t *testing.T) {
+	cases := map[string]struct {
+		min, max  int
+		expectErr bool
+	}{
+		"below max": {0, 10, false},
+		"equal":     {5, 5, false},
+		"above max": {10, 1, true},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := resourceAppstreamFleetScheduledAction().Diff(nil, terraform.NewResourceConfigRaw(map[string]interface{}{
+				"fleet_name": "test-fleet",
+				"name":       "business-hours",
+				"schedule":   "cron(0 8 ? * MON-FRI *)",
+				"scalable_target_action": []interface{}{map[string]interface{}{
+					"min_capacity": tc.min,
+					"max_capacity": tc.max,
+				}},
+			}), nil)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error %t, got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestSuppressEquivalentTime(t *testing.T) {
+	cases := []struct {
+		old, new string
+		suppress bool
+	}{
+		{"2026-11-01T08:00:00Z", "2026-11-01T08:00:00Z", true},
+		{"2026-11-01T08:00:00Z", "2026-11-01T09:00:00+01:00", true},
+		{"2026-11-01T08:00:00Z", "2026-11-01T09:00:00Z", false},
+		{"", "2026-11-01T08:00:00Z", false},
+		{"2026-11-01T08:00:00Z", "", false},
+	}
+
+	for _, tc := range cases {
+		if got := suppressEquivalentTime("start_time", tc.old, tc.new, nil); got != tc.suppress {
+			t.Errorf("%q -> %q: expected suppress %t, got %t", tc.old, tc.new, tc.suppress, got)
+		}
+	}
+}
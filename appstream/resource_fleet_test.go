@@ -0,0 +1,647 @@
+package appstream
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/appstream"
+	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+)
+
+func TestResourceAppstreamFleet_replacement(t *testing.T) {
+	testCheckSchemaReplacement(t, resourceAppstreamFleet(),
+		[]string{
+			"fleet_type",
+			"name",
+		},
+		[]string{
+			"capacity_managed_externally",
+			"compute_capacity",
+			"creation_token",
+			"deletion_protection",
+			"description",
+			"desired_state",
+			"disconnect_timeout",
+			"display_name",
+			"domain_info",
+			"enable_default_internet_access",
+			"iam_role_arn",
+			"image_arn",
+			"instance_type",
+			"max_user_duration",
+			"scale_down_protection",
+			"stack_name",
+			"tags",
+			"vpc_config",
+			"wait_for_capacity",
+		},
+	)
+}
+
+func TestResourceAppstreamFleet_diff(t *testing.T) {
+	state := map[string]string{
+		"name":                                 "test-fleet",
+		"compute_capacity.#":                   "1",
+		"compute_capacity.0.desired_instances": "1",
+		"fleet_type":                           "ON_DEMAND",
+		"iam_role_arn":                         "arn:aws:iam::123456789012:role/fleet",
+		"image_arn":                            "arn:aws:appstream:eu-west-1:123456789012:image/base",
+		"instance_type":                        "stream.standard.medium",
+		"scale_down_protection":                scaleDownProtectionDisabled,
+	}
+	config := map[string]interface{}{
+		"name":                  "test-fleet",
+		"compute_capacity":      []interface{}{map[string]interface{}{"desired_instances": 1}},
+		"fleet_type":            "ON_DEMAND",
+		"iam_role_arn":          "arn:aws:iam::123456789012:role/fleet",
+		"image_arn":             "arn:aws:appstream:eu-west-1:123456789012:image/base",
+		"instance_type":         "stream.standard.medium",
+		"scale_down_protection": scaleDownProtectionDisabled,
+	}
+
+	cases := map[string]struct {
+		key         string
+		value       interface{}
+		requiresNew bool
+	}{
+		"rename":        {"name", "other-fleet", true},
+		"fleet type":    {"fleet_type", "ALWAYS_ON", true},
+		"image":         {"image_arn", "arn:aws:appstream:eu-west-1:123456789012:image/next", false},
+		"instance type": {"instance_type", "stream.standard.large", false},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			changed := make(map[string]interface{})
+			for k, v := range config {
+				changed[k] = v
+			}
+			changed[tc.key] = tc.value
+
+			diff := testResourceDiff(t, resourceAppstreamFleet(), state, changed)
+			if diff == nil || diff.Attributes[tc.key] == nil {
+				t.Fatalf("expected a diff on %s", tc.key)
+			}
+			if diff.RequiresNew() != tc.requiresNew {
+				t.Errorf("expected RequiresNew %t, got %t", tc.requiresNew, diff.RequiresNew())
+			}
+		})
+	}
+}
+
+func TestResourceAppstreamFleet_durationBounds(t *testing.T) {
+	cases := []struct {
+		key   string
+		value int
+		valid bool
+	}{
+		{"disconnect_timeout", 59, false},
+		{"disconnect_timeout", 60, true},
+		{"disconnect_timeout", 36000, true},
+		{"disconnect_timeout", 36001, false},
+		{"max_user_duration", 599, false},
+		{"max_user_duration", 600, true},
+		{"max_user_duration", 432000, true},
+		{"max_user_duration", 432001, false},
+	}
+
+	r := resourceAppstreamFleet()
+	for _, tc := range cases {
+		_, errs := r.Schema[tc.key].ValidateFunc(tc.value, tc.key)
+		if (len(errs) == 0) != tc.valid {
+			t.Errorf("%s = %d: expected valid %t, got %v", tc.key, tc.value, tc.valid, errs)
+		}
+	}
+}
+
+func TestResourceAppstreamFleet_scaleDownProtection(t *testing.T) {
+	server := newTestAppstreamServer(t)
+	server.addFleet(&appstream.Fleet{
+		Name:      aws.String("test-fleet"),
+		FleetType: aws.String(appstream.FleetTypeOnDemand),
+		State:     aws.String(appstream.FleetStateRunning),
+		ComputeCapacityStatus: &appstream.ComputeCapacityStatus{
+			Desired: aws.Int64(5),
+			InUse:   aws.Int64(3),
+		},
+	})
+	client := server.client(t)
+
+	state := map[string]string{
+		"name":                                 "test-fleet",
+		"compute_capacity.#":                   "1",
+		"compute_capacity.0.desired_instances": "5",
+		"fleet_type":                           appstream.FleetTypeOnDemand,
+		"iam_role_arn":                         "arn:aws:iam::123456789012:role/fleet",
+		"image_arn":                            "arn:aws:appstream:eu-west-1:123456789012:image/base",
+		"instance_type":                        "stream.standard.medium",
+		"scale_down_protection":                scaleDownProtectionFail,
+	}
+	config := func(protection string, desired int) map[string]interface{} {
+		return map[string]interface{}{
+			"name":                  "test-fleet",
+			"compute_capacity":      []interface{}{map[string]interface{}{"desired_instances": desired}},
+			"fleet_type":            appstream.FleetTypeOnDemand,
+			"iam_role_arn":          "arn:aws:iam::123456789012:role/fleet",
+			"image_arn":             "arn:aws:appstream:eu-west-1:123456789012:image/base",
+			"instance_type":         "stream.standard.medium",
+			"scale_down_protection": protection,
+		}
+	}
+	plan := func(protection string, desired int) (*terraform.InstanceDiff, error) {
+		return resourceAppstreamFleet().Diff(&terraform.InstanceState{
+			ID:         "test-fleet",
+			Attributes: state,
+		}, terraform.NewResourceConfigRaw(config(protection, desired)), client)
+	}
+
+	cases := map[string]struct {
+		protection string
+		desired    int
+		expectErr  bool
+	}{
+		"below sessions in use":    {scaleDownProtectionFail, 2, true},
+		"to sessions in use":       {scaleDownProtectionFail, 3, false},
+		"scale up":                 {scaleDownProtectionFail, 8, false},
+		"protection disabled":      {scaleDownProtectionDisabled, 1, false},
+		"waiting at apply instead": {scaleDownProtectionWait, 1, false},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := plan(tc.protection, tc.desired)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error %t, got %v", tc.expectErr, err)
+			}
+		})
+	}
+
+	// Sessions started between plan and apply are checked again before UpdateFleet.
+	diff, err := plan(scaleDownProtectionFail, 3)
+	if err != nil {
+		t.Fatalf("error planning: %s", err)
+	}
+	server.fleets["test-fleet"].ComputeCapacityStatus.InUse = aws.Int64(4)
+	_, err = resourceAppstreamFleet().Apply(&terraform.InstanceState{ID: "test-fleet", Attributes: state}, diff, client)
+	if err == nil || !strings.Contains(err.Error(), "4 streaming sessions are in use") {
+		t.Fatalf("expected the scale-down to be refused at apply, got %v", err)
+	}
+	if server.calls["UpdateFleet"] != 0 {
+		t.Errorf("expected no UpdateFleet call, got %d", server.calls["UpdateFleet"])
+	}
+}
+
+func TestResourceAppstreamFleetStateUpgradeV0(t *testing.T) {
+	cases := map[string]struct {
+		state    map[string]interface{}
+		expected map[string]interface{}
+	}{
+		"state": {
+			map[string]interface{}{"name": "test-fleet", "state": "RUNNING"},
+			map[string]interface{}{"name": "test-fleet", "state": "RUNNING"},
+		},
+		"no state": {
+			map[string]interface{}{"name": "test-fleet"},
+			map[string]interface{}{"name": "test-fleet"},
+		},
+		"vpc config": {
+			map[string]interface{}{"name": "test-fleet", "vpc_config": []interface{}{map[string]interface{}{
+				"security_group_ids": "",
+				"subnet_ids":         "subnet-7a5f4b51, subnet-7a5f1231",
+			}}},
+			map[string]interface{}{"name": "test-fleet", "vpc_config": []interface{}{map[string]interface{}{
+				"security_group_ids": []interface{}{},
+				"subnet_ids":         []interface{}{"subnet-7a5f4b51", "subnet-7a5f1231"},
+			}}},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			tc.expected["capacity_managed_externally"] = false
+			tc.expected["deletion_protection"] = false
+			tc.expected["scale_down_protection"] = scaleDownProtectionDisabled
+
+			got, err := resourceAppstreamFleetStateUpgradeV0(tc.state, nil)
+			if err != nil {
+				t.Fatalf("error upgrading: %s", err)
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestResourceAppstreamFleetStateUpgradeV0_plan(t *testing.T) {
+	upgraded, err := resourceAppstreamFleetStateUpgradeV0(map[string]interface{}{
+		"name":             "test-fleet",
+		"compute_capacity": []interface{}{map[string]interface{}{"desired_instances": 2}},
+		"fleet_type":       appstream.FleetTypeOnDemand,
+		"iam_role_arn":     "arn:aws:iam::123456789012:role/fleet",
+		"image_arn":        "arn:aws:appstream:eu-west-1:123456789012:image/base",
+		"instance_type":    "stream.standard.medium",
+		"state":            appstream.FleetStateRunning,
+	}, nil)
+	if err != nil {
+		t.Fatalf("error upgrading: %s", err)
+	}
+
+	r := resourceAppstreamFleet()
+	d := r.Data(&terraform.InstanceState{ID: "test-fleet"})
+	for k, v := range upgraded {
+		if err := d.Set(k, v); err != nil {
+			t.Fatalf("error setting %s: %s", k, err)
+		}
+	}
+
+	// A fleet whose version 0 configuration never set state plans no change.
+	diff, err := r.Diff(d.State(), terraform.NewResourceConfigRaw(map[string]interface{}{
+		"name":             "test-fleet",
+		"compute_capacity": []interface{}{map[string]interface{}{"desired_instances": 2}},
+		"fleet_type":       appstream.FleetTypeOnDemand,
+		"iam_role_arn":     "arn:aws:iam::123456789012:role/fleet",
+		"image_arn":        "arn:aws:appstream:eu-west-1:123456789012:image/base",
+		"instance_type":    "stream.standard.medium",
+	}), nil)
+	if err != nil {
+		t.Fatalf("error planning: %s", err)
+	}
+	if !diff.Empty() {
+		t.Errorf("expected no change after the upgrade, got %v", diff.Attributes)
+	}
+}
+
+func TestResourceAppstreamFleet_resumeCreate(t *testing.T) {
+	server := newTestAppstreamServer(t)
+	server.addStack(&appstream.Stack{Name: aws.String("desktop")})
+	client := server.client(t)
+
+	r := resourceAppstreamFleet()
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"name":             "test-fleet",
+		"compute_capacity": []interface{}{map[string]interface{}{"desired_instances": 2}},
+		"desired_state":    appstream.FleetStateRunning,
+		"fleet_type":       appstream.FleetTypeOnDemand,
+		"iam_role_arn":     "arn:aws:iam::123456789012:role/fleet",
+		"image_arn":        "arn:aws:appstream:eu-west-1:123456789012:image/base",
+		"instance_type":    "stream.standard.medium",
+		"stack_name":       "desktop",
+	})
+
+	// A failed association fails the create but keeps the fleet and the finished steps in state.
+	server.fail["AssociateFleet"] = true
+	diff, err := r.Diff(nil, config, client)
+	if err != nil {
+		t.Fatalf("error planning: %s", err)
+	}
+	state, err := r.Apply(nil, diff, client)
+	if err == nil || !strings.Contains(err.Error(), "AssociateFleet failed") {
+		t.Fatalf("expected the association error, got %v", err)
+	}
+	if state == nil || state.ID != "test-fleet" {
+		t.Fatalf("expected the created fleet to be kept in state, got %v", state)
+	}
+	if state.Attributes["instance_type"] != "stream.standard.medium" || state.Attributes["compute_capacity.0.desired_instances"] != "2" {
+		t.Errorf("expected the created arguments in state, got %v", state.Attributes)
+	}
+	if state.Attributes["stack_name"] != "" || state.Attributes["desired_state"] != "" {
+		t.Fatalf("expected the unfinished steps to be left out of state, got %v", state.Attributes)
+	}
+	if got := aws.StringValue(server.fleets["test-fleet"].State); got != appstream.FleetStateStopped {
+		t.Fatalf("expected the fleet to stay STOPPED after the failed association, got %s", got)
+	}
+
+	// The next apply resumes the association and the start as in-place changes.
+	server.fail["AssociateFleet"] = false
+	state, err = r.RefreshWithoutUpgrade(state, client)
+	if err != nil {
+		t.Fatalf("error refreshing: %s", err)
+	}
+	diff, err = r.Diff(state, config, client)
+	if err != nil {
+		t.Fatalf("error planning: %s", err)
+	}
+	for _, k := range []string{"stack_name", "desired_state"} {
+		if diff.Attributes[k] == nil || diff.Attributes[k].RequiresNew {
+			t.Errorf("expected an in-place change on %s, got %v", k, diff.Attributes[k])
+		}
+	}
+	if diff.RequiresNew() {
+		t.Fatal("expected the fleet to be kept")
+	}
+	state, err = r.Apply(state, diff, client)
+	if err != nil {
+		t.Fatalf("error resuming: %s", err)
+	}
+	if !reflect.DeepEqual(server.associations["desktop"], []string{"test-fleet"}) {
+		t.Errorf("expected the fleet to be associated, got %v", server.associations["desktop"])
+	}
+	if got := aws.StringValue(server.fleets["test-fleet"].State); got != appstream.FleetStateRunning {
+		t.Errorf("expected the fleet to be RUNNING, got %s", got)
+	}
+	if server.calls["CreateFleet"] != 1 {
+		t.Errorf("expected a single CreateFleet call, got %d", server.calls["CreateFleet"])
+	}
+	token := aws.StringValue(server.tags["arn:aws:appstream:eu-west-1:123456789012:fleet/test-fleet"][managedByTagKey])
+	if token != "test-fleet" || state.Attributes["creation_token"] != token {
+		t.Errorf("expected the default creation token test-fleet on the fleet and in state, got %q and %q", token, state.Attributes["creation_token"])
+	}
+
+	diff, err = r.Diff(state, config, client)
+	if err != nil {
+		t.Fatalf("error planning: %s", err)
+	}
+	if !diff.Empty() {
+		t.Errorf("expected an empty plan after resuming, got %v", diff)
+	}
+}
+
+func TestResourceAppstreamFleet_createWaitForCapacityTimeout(t *testing.T) {
+	server := newTestAppstreamServer(t)
+	server.fleetErrors["test-fleet"] = []*appstream.FleetError{{
+		ErrorCode:    aws.String(appstream.FleetErrorCodeSubnetHasInsufficientIpAddresses),
+		ErrorMessage: aws.String("subnet-7a5f4b51 has no free addresses"),
+	}}
+	client := server.client(t)
+
+	r := resourceAppstreamFleet()
+	diff, err := r.Diff(nil, terraform.NewResourceConfigRaw(map[string]interface{}{
+		"name":              "test-fleet",
+		"compute_capacity":  []interface{}{map[string]interface{}{"desired_instances": 2}},
+		"desired_state":     appstream.FleetStateRunning,
+		"fleet_type":        appstream.FleetTypeOnDemand,
+		"iam_role_arn":      "arn:aws:iam::123456789012:role/fleet",
+		"image_arn":         "arn:aws:appstream:eu-west-1:123456789012:image/base",
+		"instance_type":     "stream.standard.medium",
+		"wait_for_capacity": []interface{}{map[string]interface{}{"timeout": "1ns"}},
+	}), client)
+	if err != nil {
+		t.Fatalf("error planning: %s", err)
+	}
+
+	state, err := r.Apply(nil, diff, client)
+	if err == nil || !strings.Contains(err.Error(), "0 of 2 instances available") || !strings.Contains(err.Error(), appstream.FleetErrorCodeSubnetHasInsufficientIpAddresses) {
+		t.Fatalf("expected the capacity timeout with the fleet errors, got %v", err)
+	}
+	if state == nil || state.ID != "test-fleet" || state.Attributes["desired_state"] != appstream.FleetStateRunning {
+		t.Fatalf("expected the started fleet to be kept in state, got %v", state)
+	}
+	if state.Attributes["wait_for_capacity.#"] != "" {
+		t.Errorf("expected the capacity wait to be left out of state, got %v", state.Attributes)
+	}
+}
+
+func TestResourceAppstreamFleet_import(t *testing.T) {
+	server := newTestAppstreamServer(t)
+	server.pageSize = 1
+	for _, name := range []string{"a-fleet", "test-fleet"} {
+		server.addFleet(&appstream.Fleet{
+			Name:                  aws.String(name),
+			ComputeCapacityStatus: &appstream.ComputeCapacityStatus{Desired: aws.Int64(2)},
+			FleetType:             aws.String(appstream.FleetTypeOnDemand),
+			InstanceType:          aws.String("stream.standard.medium"),
+			State:                 aws.String(appstream.FleetStateRunning),
+		})
+	}
+	server.tags["arn:aws:appstream:eu-west-1:123456789012:fleet/test-fleet"][deletionProtectionTagKey] = aws.String("true")
+	client := server.client(t)
+
+	state := testImportState(t, resourceAppstreamFleet(), "test-fleet", client)
+	for k, v := range map[string]string{
+		"name":                                 "test-fleet",
+		"compute_capacity.0.desired_instances": "2",
+		"deletion_protection":                  "true",
+		"instance_type":                        "stream.standard.medium",
+		"state":                                appstream.FleetStateRunning,
+	} {
+		if state.Attributes[k] != v {
+			t.Errorf("expected %s %q, got %q", k, v, state.Attributes[k])
+		}
+	}
+
+	state, err := resourceAppstreamFleet().RefreshWithoutUpgrade(&terraform.InstanceState{ID: "missing-fleet"}, client)
+	if err != nil {
+		t.Fatalf("error refreshing: %s", err)
+	}
+	if state != nil && state.ID != "" {
+		t.Errorf("expected a missing fleet to be removed from state, got %v", state)
+	}
+}
+
+func TestResourceAppstreamFleet_updateStoppedOnly(t *testing.T) {
+	cases := map[string]struct {
+		fleetState    string
+		change        map[string]interface{}
+		expectedState string
+		stops         int
+		starts        int
+	}{
+		"instance type on a running fleet": {
+			appstream.FleetStateRunning, map[string]interface{}{"instance_type": "stream.standard.large"},
+			appstream.FleetStateRunning, 1, 1,
+		},
+		"display name on a running fleet": {
+			appstream.FleetStateRunning, map[string]interface{}{"display_name": "Desktop"},
+			appstream.FleetStateRunning, 0, 0,
+		},
+		"description with a stop": {
+			appstream.FleetStateRunning, map[string]interface{}{"description": "Stopped", "desired_state": appstream.FleetStateStopped},
+			appstream.FleetStateStopped, 1, 0,
+		},
+		"instance type on a stopped fleet": {
+			appstream.FleetStateStopped, map[string]interface{}{"instance_type": "stream.standard.large"},
+			appstream.FleetStateStopped, 0, 0,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			server := newTestAppstreamServer(t)
+			server.addFleet(&appstream.Fleet{
+				Name:                  aws.String("test-fleet"),
+				ComputeCapacityStatus: &appstream.ComputeCapacityStatus{Desired: aws.Int64(2)},
+				FleetType:             aws.String(appstream.FleetTypeOnDemand),
+				IamRoleArn:            aws.String("arn:aws:iam::123456789012:role/fleet"),
+				ImageArn:              aws.String("arn:aws:appstream:eu-west-1:123456789012:image/base"),
+				InstanceType:          aws.String("stream.standard.medium"),
+				State:                 aws.String(tc.fleetState),
+			})
+			client := server.client(t)
+
+			r := resourceAppstreamFleet()
+			state := testImportState(t, r, "test-fleet", client)
+			config := map[string]interface{}{
+				"name":             "test-fleet",
+				"compute_capacity": []interface{}{map[string]interface{}{"desired_instances": 2}},
+				"fleet_type":       appstream.FleetTypeOnDemand,
+				"iam_role_arn":     "arn:aws:iam::123456789012:role/fleet",
+				"image_arn":        "arn:aws:appstream:eu-west-1:123456789012:image/base",
+				"instance_type":    "stream.standard.medium",
+			}
+			for k, v := range tc.change {
+				config[k] = v
+			}
+			diff, err := r.Diff(state, terraform.NewResourceConfigRaw(config), client)
+			if err != nil {
+				t.Fatalf("error planning: %s", err)
+			}
+			if diff.RequiresNew() {
+				t.Fatal("expected an in-place update")
+			}
+
+			state, err = r.Apply(state, diff, client)
+			if err != nil {
+				t.Fatalf("error updating: %s", err)
+			}
+			for k, v := range tc.change {
+				if state.Attributes[k] != v {
+					t.Errorf("expected %s %q, got %q", k, v, state.Attributes[k])
+				}
+			}
+			if got := aws.StringValue(server.fleets["test-fleet"].State); got != tc.expectedState {
+				t.Errorf("expected the fleet to be %s, got %s", tc.expectedState, got)
+			}
+			if server.calls["StopFleet"] != tc.stops || server.calls["StartFleet"] != tc.starts {
+				t.Errorf("expected %d StopFleet and %d StartFleet calls, got %d and %d", tc.stops, tc.starts, server.calls["StopFleet"], server.calls["StartFleet"])
+			}
+		})
+	}
+}
+
+func TestResourceAppstreamFleet_adopt(t *testing.T) {
+	// tag is the creation token the existing fleet was tagged with, token the configured one.
+	cases := map[string]struct {
+		tag       string
+		token     string
+		expectErr bool
+	}{
+		"same creation token":              {"ci-desktop", "ci-desktop", false},
+		"other creation token":             {"ci-desktop", "ci-kiosk", true},
+		"no creation token":                {"ci-desktop", "", true},
+		"default creation token":           {"test-fleet", "", false},
+		"not created by the provider":      {"", "", true},
+		"not created by the provider, set": {"", "ci-desktop", true},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			server := newTestAppstreamServer(t)
+			server.addFleet(&appstream.Fleet{
+				Name:  aws.String("test-fleet"),
+				State: aws.String(appstream.FleetStateRunning),
+			})
+			if tc.tag != "" {
+				server.tags["arn:aws:appstream:eu-west-1:123456789012:fleet/test-fleet"][managedByTagKey] = aws.String(tc.tag)
+			}
+			client := server.client(t)
+
+			raw := map[string]interface{}{
+				"name":             "test-fleet",
+				"compute_capacity": []interface{}{map[string]interface{}{"desired_instances": 1}},
+				"fleet_type":       appstream.FleetTypeOnDemand,
+				"iam_role_arn":     "arn:aws:iam::123456789012:role/fleet",
+				"image_arn":        "arn:aws:appstream:eu-west-1:123456789012:image/base",
+				"instance_type":    "stream.standard.medium",
+			}
+			if tc.token != "" {
+				raw["creation_token"] = tc.token
+			}
+			r := resourceAppstreamFleet()
+			diff, err := r.Diff(nil, terraform.NewResourceConfigRaw(raw), client)
+			if err != nil {
+				t.Fatalf("error planning: %s", err)
+			}
+
+			state, err := r.Apply(nil, diff, client)
+			if tc.expectErr {
+				if err == nil || !strings.Contains(err.Error(), "already exists") {
+					t.Fatalf("expected an already exists error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error adopting: %s", err)
+			}
+			if state.ID != "test-fleet" || state.Attributes["creation_token"] != tc.tag {
+				t.Errorf("expected the fleet to be adopted with its creation token, got %s: %v", state.ID, state.Attributes)
+			}
+			if server.calls["CreateFleet"] != 0 {
+				t.Errorf("expected no CreateFleet call, got %d", server.calls["CreateFleet"])
+			}
+		})
+	}
+}
+
+func TestResourceAppstreamFleet_waitForCapacityDiff(t *testing.T) {
+	cases := map[string]struct {
+		raw       map[string]interface{}
+		expectErr bool
+	}{
+		"below desired": {map[string]interface{}{"min_available": 2}, false},
+		"desired":       {map[string]interface{}{"min_available": 0}, false},
+		"above desired": {map[string]interface{}{"min_available": 3}, true},
+		"above externally managed desired": {
+			map[string]interface{}{"min_available": 3, "capacity_managed_externally": true},
+			false,
+		},
+		"stopped": {map[string]interface{}{"desired_state": appstream.FleetStateStopped}, true},
+		"elastic": {map[string]interface{}{"fleet_type": appstream.FleetTypeElastic}, true},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			raw := map[string]interface{}{
+				"name":             "test-fleet",
+				"compute_capacity": []interface{}{map[string]interface{}{"desired_instances": 2}},
+				"fleet_type":       appstream.FleetTypeOnDemand,
+				"iam_role_arn":     "arn:aws:iam::123456789012:role/fleet",
+				"image_arn":        "arn:aws:appstream:eu-west-1:123456789012:image/base",
+				"instance_type":    "stream.standard.medium",
+			}
+			waitForCapacity := map[string]interface{}{}
+			for k, v := range tc.raw {
+				if k == "min_available" {
+					waitForCapacity[k] = v
+				} else {
+					raw[k] = v
+				}
+			}
+			raw["wait_for_capacity"] = []interface{}{waitForCapacity}
+
+			_, err := resourceAppstreamFleet().Diff(nil, terraform.NewResourceConfigRaw(raw), nil)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error %t, got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestWaitForAppstreamFleetCapacity(t *testing.T) {
+	server := newTestAppstreamServer(t)
+	for name, state := range map[string]string{
+		"running-fleet": appstream.FleetStateRunning,
+		"stopped-fleet": appstream.FleetStateStopped,
+	} {
+		server.addFleet(&appstream.Fleet{
+			Name:  aws.String(name),
+			State: aws.String(state),
+			ComputeCapacityStatus: &appstream.ComputeCapacityStatus{
+				Available: aws.Int64(0),
+				Desired:   aws.Int64(2),
+			},
+		})
+	}
+	server.fleets["running-fleet"].ComputeCapacityStatus.Available = aws.Int64(2)
+	svc := server.client(t).appstreamconn
+
+	if err := waitForAppstreamFleetCapacity(svc, "running-fleet", 0, time.Minute); err != nil {
+		t.Errorf("expected the running fleet to have its capacity, got %s", err)
+	}
+	err := waitForAppstreamFleetCapacity(svc, "stopped-fleet", 0, time.Hour)
+	if err == nil || !strings.Contains(err.Error(), "is STOPPED") {
+		t.Errorf("expected a stopped fleet to fail at once, got %v", err)
+	}
+}
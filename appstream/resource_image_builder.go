@@ -1,365 +1,518 @@
 package appstream
 
 import (
-    "github.com/aws/aws-sdk-go/aws"
-    "github.com/aws/aws-sdk-go/service/appstream"
-    "github.com/hashicorp/terraform-plugin-sdk/helper/schema"
-    "log"
-    "strings"
-    "time"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/appstream"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
 )
 
-
 func resourceAppstreamImageBuilder() *schema.Resource {
-	return &schema.Resource {
-        Create: resourceAppstreamImageBuilderCreate,
-        Read:   resourceAppstreamImageBuilderRead,
-        Update: resourceAppstreamImageBuilderUpdate,
-        Delete: resourceAppstreamImageBuilderDelete,
-        Importer: &schema.ResourceImporter {
-            State: schema.ImportStatePassthrough,
-        },
-
-        Schema: map[string]*schema.Schema{
-            "name": {
-                Type:         schema.TypeString,
-                Required:     true,
-            },
-            "appstream_agent_version": {
-                Type:         schema.TypeString,
-                Optional:     true,
-            },
-            "description": {
-                Type:         schema.TypeString,
-                Optional:     true,
-            },
-
-            "display_name": {
-                Type:         schema.TypeString,
-                Optional:     true,
-            },
-
-            "domain_info": {
-                Type:         schema.TypeList,
-                Optional:     true,
-                Elem:         &schema.Resource{
-                    Schema: map[string]*schema.Schema{
-                        "directory_name": {
-                            Type:       schema.TypeString,
-                            Optional:   true,
-                        },
-                        "organizational_unit_distinguished_name": {
-                            Type:       schema.TypeString,
-                            Optional:   true,
-                        },
-                    },
-                },
-            },
-
-            "enable_default_internet_access": {
-                Type:         schema.TypeBool,
-                Optional:     true,
-            },
-
-            "image_arn": {
-                Type:         schema.TypeString,
-                Required:     true,
-                ForceNew:	  true,
-            },
-
-            "instance_type": {
-                Type:         schema.TypeString,
-                Required:     true,
-            },
-
-            "state" : {
-                Type:         schema.TypeString,
-                Optional:       true,
-            },
-
-            "vpc_config": {
-                Type:         schema.TypeList,
-                Optional:     true,
-                Elem:         &schema.Resource {
-                    Schema: map[string]*schema.Schema {
-                        "security_group_ids": {
-                            Type:       schema.TypeString,
-                            Optional:   true,
-                        },
-                        "subnet_ids":   {
-                            Type:       schema.TypeString,
-                            Optional:   true,
-                        },
-                    },
-                },
-            },
-        },
-    }
+	return &schema.Resource{
+		Create: resourceAppstreamImageBuilderCreate,
+		Read:   resourceAppstreamImageBuilderRead,
+		Update: resourceAppstreamImageBuilderUpdate,
+		Delete: resourceAppstreamImageBuilderDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(60 * time.Minute),
+			Update: schema.DefaultTimeout(60 * time.Minute),
+		},
+
+		SchemaVersion: 1,
+		StateUpgraders: []schema.StateUpgrader{
+			{
+				Type:    resourceAppstreamImageBuilderV0().CoreConfigSchema().ImpliedType(),
+				Upgrade: resourceAppstreamImageBuilderStateUpgradeV0,
+				Version: 0,
+			},
+		},
+
+		Schema: map[string]*schema.Schema{
+			"access_endpoints": accessEndpointsSchema(true),
+
+			"name": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateAppstreamName,
+			},
+			"appstream_agent_version": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				ForceNew:         true,
+				DiffSuppressFunc: suppressLatestAgentVersion,
+			},
+			// Tagged on the builder; create only adopts an existing builder carrying the same token.
+			// Defaults to the name, so an interrupted create is resumed without setting it.
+			"creation_token": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.StringLenBetween(1, 256),
+			},
+			"description": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringLenBetween(0, 256),
+			},
+
+			"desired_state": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{appstream.ImageBuilderStateRunning, appstream.ImageBuilderStateStopped}, false),
+			},
+
+			"display_name": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringLenBetween(0, 100),
+			},
+
+			"domain_info": domainInfoSchema(true),
+
+			"enable_default_internet_access": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			"image_arn": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateAppstreamImageArn,
+			},
+
+			"instance_type": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateAppstreamInstanceType,
+			},
+
+			"state": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"tags": {
+				Type:     schema.TypeMap,
+				Optional: true,
+			},
+
+			"vpc_config": vpcConfigSchema(true),
+		},
+	}
 }
 
 func resourceAppstreamImageBuilderCreate(d *schema.ResourceData, meta interface{}) error {
 
 	svc := meta.(*AWSClient).appstreamconn
 
-    CreateImageBuilderInputOpts := &appstream.CreateImageBuilderInput{}
+	CreateImageBuilderInputOpts := &appstream.CreateImageBuilderInput{}
 
-    if v, ok := d.GetOk("name"); ok {
-        CreateImageBuilderInputOpts.Name = aws.String(v.(string))
-    }
+	if v, ok := d.GetOk("name"); ok {
+		CreateImageBuilderInputOpts.Name = aws.String(v.(string))
+	}
 
-    if v, ok := d.GetOk("appstream_agent_version"); ok {
-        CreateImageBuilderInputOpts.AppstreamAgentVersion = aws.String(v.(string))
-    }
+	if v, ok := d.GetOk("access_endpoints"); ok {
+		CreateImageBuilderInputOpts.AccessEndpoints = expandAccessEndpoints(v.(*schema.Set).List())
+	}
 
-    if v, ok := d.GetOk("description"); ok {
-        CreateImageBuilderInputOpts.Description = aws.String(v.(string))
-    }
+	if v, ok := d.GetOk("appstream_agent_version"); ok {
+		CreateImageBuilderInputOpts.AppstreamAgentVersion = aws.String(v.(string))
+	}
 
-    if v, ok := d.GetOk("display_name"); ok {
-        CreateImageBuilderInputOpts.DisplayName = aws.String(v.(string))
-    }
+	if v, ok := d.GetOk("description"); ok {
+		CreateImageBuilderInputOpts.Description = aws.String(v.(string))
+	}
 
-    DomainJoinInfoConfig := &appstream.DomainJoinInfo{}
+	if v, ok := d.GetOk("display_name"); ok {
+		CreateImageBuilderInputOpts.DisplayName = aws.String(v.(string))
+	}
 
-    if dom, ok := d.GetOk("domain_info"); ok {
-        DomainAttributes := dom.([]interface{})
-	attr := DomainAttributes[0].(map[string]interface{})
-	if v, ok := attr["directory_name"]; ok {
-		DomainJoinInfoConfig.DirectoryName = aws.String(v.(string))
+	if v, ok := d.GetOk("domain_info"); ok {
+		CreateImageBuilderInputOpts.DomainJoinInfo = expandDomainJoinInfo(v.([]interface{}))
 	}
-	if v, ok := attr["organizational_unit_distinguished_name"]; ok {
-		DomainJoinInfoConfig.OrganizationalUnitDistinguishedName = aws.String(v.(string))
+
+	if v, ok := d.GetOk("enable_default_internet_access"); ok {
+		CreateImageBuilderInputOpts.EnableDefaultInternetAccess = aws.Bool(v.(bool))
 	}
-	CreateImageBuilderInputOpts.DomainJoinInfo = DomainJoinInfoConfig
-    }
 
-    if v, ok := d.GetOk("enable_default_internet_access"); ok {
-        CreateImageBuilderInputOpts.EnableDefaultInternetAccess = aws.Bool(v.(bool))
-    }
+	if v, ok := d.GetOk("image_arn"); ok {
+		CreateImageBuilderInputOpts.ImageArn = aws.String(v.(string))
+	}
 
-    if v, ok := d.GetOk("image_arn"); ok {
-        CreateImageBuilderInputOpts.ImageArn = aws.String(v.(string))
-    }
+	if v, ok := d.GetOk("instance_type"); ok {
+		CreateImageBuilderInputOpts.InstanceType = aws.String(v.(string))
+	}
 
-    if v, ok := d.GetOk("instance_type"); ok {
-        CreateImageBuilderInputOpts.InstanceType = aws.String(v.(string))
-    }
+	if v, ok := d.GetOk("vpc_config"); ok {
+		CreateImageBuilderInputOpts.VpcConfig = expandVpcConfig(v.([]interface{}))
+	}
 
-    VpcConfigConfig := & appstream.VpcConfig{}
+	tags := New(d.Get("tags").(map[string]interface{})).IgnoreAWS().IgnoreProvider()
+	token := d.Get("creation_token").(string)
+	if token == "" {
+		token = defaultCreationToken(aws.StringValue(CreateImageBuilderInputOpts.Name))
+	}
+	tags = tags.Merge(managedByTags(token))
+	CreateImageBuilderInputOpts.Tags = Tags(tags)
+
+	ImageBuilderName := aws.StringValue(CreateImageBuilderInputOpts.Name)
+	builder, err := describeImageBuilder(svc, ImageBuilderName)
+	if err != nil {
+		return err
+	}
 
-    if vpc, ok := d.GetOk("vpc_config"); ok {
-        VpcAttributes := vpc.([]interface{})
-        attr := VpcAttributes[0].(map[string]interface{})
-        if v, ok := attr["security_group_ids"]; ok {
-            strSlice := strings.Split(v.(string), ",")
-                for i, s := range strSlice {
-		    strSlice[i] = strings.TrimSpace(s)
+	if builder != nil {
+		// A builder tagged with the same creation_token is left over from a create of
+		// this configuration that was interrupted while waiting; adopt it and keep waiting.
+		owner, err := managedByToken(svc, aws.StringValue(builder.Arn))
+		if err != nil {
+			return err
 		}
-            VpcConfigConfig.SecurityGroupIds = aws.StringSlice(strSlice)
-        }
-        if v, ok := attr["subnet_ids"]; ok {
-            strSlice := strings.Split(v.(string), ",")
-                for i, s := range strSlice {
-		    strSlice[i] = strings.TrimSpace(s)
+		if owner != token {
+			return fmt.Errorf("Appstream Image Builder (%s) already exists and was not created with this creation_token, import it instead", ImageBuilderName)
 		}
-            VpcConfigConfig.SubnetIds = aws.StringSlice(strSlice)
-        }
-        CreateImageBuilderInputOpts.VpcConfig = VpcConfigConfig
-    }
+		log.Printf("[INFO] Adopting Appstream Image Builder (%s) left behind by an interrupted create", ImageBuilderName)
+	} else {
+		log.Printf("[DEBUG] Run configuration: %s", CreateImageBuilderInputOpts)
 
-    log.Printf("[DEBUG] Run configuration: %s", CreateImageBuilderInputOpts)
+		resp, err := svc.CreateImageBuilder(CreateImageBuilderInputOpts)
 
-    resp, err := svc.CreateImageBuilder(CreateImageBuilderInputOpts)
+		if err != nil {
+			log.Printf("[ERROR] Error creating Appstream Image Builder: %s", err)
+			return err
+		}
 
-    if err != nil {
-	log.Printf("[ERROR] Error creating Appstream Image Builder: %s", err)
-	return err
-    }
+		log.Printf("[DEBUG] Image builder created %s", resp)
+	}
 
-    log.Printf("[DEBUG] Image builder created %s", resp)
+	if v, ok := d.GetOk("desired_state"); ok {
+		if err := reconcileAppstreamImageBuilderState(svc, ImageBuilderName, v.(string), d.Timeout(schema.TimeoutCreate)); err != nil {
+			return err
+		}
+	} else if _, err := waitForAppstreamImageBuilderStable(svc, ImageBuilderName, d.Timeout(schema.TimeoutCreate)); err != nil {
+		return err
+	}
 
-    ImageBuilderName := aws.StringValue(CreateImageBuilderInputOpts.Name)
-    for {
+	d.SetId(*CreateImageBuilderInputOpts.Name)
+	d.Set("creation_token", token)
 
-        resp, err := svc.DescribeImageBuilders(&appstream.DescribeImageBuildersInput{
-            Names:  aws.StringSlice([]string{ImageBuilderName}),
-        })
+	return resourceAppstreamImageBuilderRead(d, meta)
+}
 
-        if err != nil {
-            log.Printf("[ERROR] Error describing Appstream Image Builder: %s", err)
-            return err
-        }
+func resourceAppstreamImageBuilderRead(d *schema.ResourceData, meta interface{}) error {
 
-        state := resp.ImageBuilders[0].State
-        if aws.StringValue(state) == "RUNNING" {
-            break
-        }
-        if aws.StringValue(state) != "RUNNING" {
-            log.Printf("[DEBUG] Image Builder not running")
-            time.Sleep(20 * time.Second)
-            continue
-        }
+	svc := meta.(*AWSClient).appstreamconn
 
-    }
+	v, err := describeImageBuilder(svc, d.Id())
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		log.Printf("[WARN] Appstream Image Builder (%s) not found, removing from state", d.Id())
+		d.SetId("")
+		return nil
+	}
 
-    d.SetId(*CreateImageBuilderInputOpts.Name)
+	d.Set("name", v.Name)
+	d.Set("description", v.Description)
+	d.Set("display_name", v.DisplayName)
+	d.Set("appstream_agent_version", v.AppstreamAgentVersion)
+	d.Set("enable_default_internet_access", v.EnableDefaultInternetAccess)
+	d.Set("instance_type", v.InstanceType)
+	// The configured image_arn is kept, it is only read back on import.
+	if d.Get("image_arn").(string) == "" {
+		d.Set("image_arn", v.ImageArn)
+	}
+	d.Set("state", v.State)
+	if d.Get("desired_state").(string) != "" {
+		d.Set("desired_state", stableImageBuilderState(aws.StringValue(v.State), d.Get("desired_state").(string)))
+	}
+	if err := d.Set("access_endpoints", flattenAccessEndpoints(v.AccessEndpoints)); err != nil {
+		log.Printf("[ERROR] Error setting access endpoints: %s", err)
+		return err
+	}
+	if err := d.Set("domain_info", flattenDomainJoinInfo(v.DomainJoinInfo)); err != nil {
+		log.Printf("[ERROR] Error setting domain info: %s", err)
+		return err
+	}
+	if err := d.Set("vpc_config", flattenVpcConfig(v.VpcConfig)); err != nil {
+		log.Printf("[ERROR] Error setting vpc config: %s", err)
+		return err
+	}
 
-    return resourceAppstreamImageBuilderRead(d, meta)
+	tg, err := svc.ListTagsForResource(&appstream.ListTagsForResourceInput{
+		ResourceArn: v.Arn,
+	})
+	if err != nil {
+		log.Printf("[ERROR] Error listing image builder tags: %s", err)
+		return err
+	}
+	d.Set("creation_token", aws.StringValue(tg.Tags[managedByTagKey]))
+	if err := d.Set("tags", New(tg.Tags).IgnoreAWS().IgnoreProvider().Map()); err != nil {
+		log.Printf("[ERROR] Error setting image builder tags: %s", err)
+		return err
+	}
+	return nil
 }
 
-func resourceAppstreamImageBuilderRead(d *schema.ResourceData, meta interface{}) error {
+// Apstream2.0 doesn't support imageBuilder updates, only tags and desired_state change in place
+func resourceAppstreamImageBuilderUpdate(d *schema.ResourceData, meta interface{}) error {
 
 	svc := meta.(*AWSClient).appstreamconn
 
-    resp, err := svc.DescribeImageBuilders(&appstream.DescribeImageBuildersInput{})
-    if err != nil {
-        log.Printf("[ERROR] Error describing Appstream Image Builder: %s", err)
-        return err
-    }
-
-
-    for _, v := range resp.ImageBuilders {
-
-        if aws.StringValue(v.Name) == d.Get("name") {
-            d.Set("name", v.Name)
-            d.Set("description", v.Description)
-            d.Set("display_name", v.DisplayName)
-            d.Set("appstream_agent_version", v.AppstreamAgentVersion)
-            d.Set("enable_default_internet_access", v.EnableDefaultInternetAccess)
-            d.Set("instance_type", v.InstanceType)
-            d.Set("image_arn", d.Get("image_arn"))
-            d.Set("state", v.State)
-            if v.VpcConfig != nil {
-                vpc_attr := map[string]interface{}{}
-                vpc_config_sg := aws.StringValueSlice(v.VpcConfig.SecurityGroupIds)
-                vpc_config_sub := aws.StringValueSlice(v.VpcConfig.SubnetIds)
-                vpc_attr["security_group_ids"] = aws.String(strings.Join(vpc_config_sg, ","))
-                vpc_attr["subnet_ids"] = aws.String(strings.Join(vpc_config_sub, ","))
-                d.Set("vpc_config", vpc_attr)
-            }
-            return nil
-        }
-    }
-
-    d.SetId("")
-    return nil
+	d.Partial(true)
 
-}
+	if d.HasChange("tags") || d.HasChange("creation_token") {
+		builder, err := describeImageBuilder(svc, d.Id())
+		if err != nil {
+			return err
+		}
+		if builder == nil {
+			return fmt.Errorf("Appstream Image Builder (%s) not found", d.Id())
+		}
 
-// Apstream2.0 doesn't support imageBuilder updates
-func resourceAppstreamImageBuilderUpdate(d *schema.ResourceData, meta interface{}) error {
+		if d.HasChange("tags") {
+			o, n := d.GetChange("tags")
+			if err := UpdateTags(svc, aws.StringValue(builder.Arn), o, n); err != nil {
+				return err
+			}
+			d.SetPartial("tags")
+		}
 
-	svc := meta.(*AWSClient).appstreamconn
+		if d.HasChange("creation_token") {
+			if err := updateManagedBy(svc, aws.StringValue(builder.Arn), d.Get("creation_token").(string)); err != nil {
+				return err
+			}
+			d.SetPartial("creation_token")
+		}
+	}
 
-    StartImageBuilderInputOptions := &appstream.StartImageBuilderInput{}
-    StopImageBuilderInputOptions := &appstream.StopImageBuilderInput{}
+	if d.HasChange("desired_state") {
+		if v := d.Get("desired_state").(string); v != "" {
+			if err := reconcileAppstreamImageBuilderState(svc, d.Id(), v, d.Timeout(schema.TimeoutUpdate)); err != nil {
+				return err
+			}
+		}
+		d.SetPartial("desired_state")
+	}
 
-    d.Partial(true)
+	d.Partial(false)
+	return resourceAppstreamImageBuilderRead(d, meta)
 
-    if v, ok := d.GetOk("name"); ok {
-        StartImageBuilderInputOptions.Name = aws.String(v.(string))
-        StopImageBuilderInputOptions.Name = aws.String(v.(string))
-    }
+}
 
-    desired_state := d.Get("state")
+func resourceAppstreamImageBuilderDelete(d *schema.ResourceData, meta interface{}) error {
+	svc := meta.(*AWSClient).appstreamconn
 
-    if d.HasChange("state") {
-        d.SetPartial("state")
-        if desired_state == "STOPPED" {
-            svc.StopImageBuilder(StopImageBuilderInputOptions)
-        } else if desired_state == "RUNNING" {
-            svc.StartImageBuilder(StartImageBuilderInputOptions)
-        }
+	ImageBuilderName := d.Id()
 
-        for {
+	resp, err := svc.DescribeImageBuilders(&appstream.DescribeImageBuildersInput{
+		Names: aws.StringSlice([]string{ImageBuilderName}),
+	})
 
-            resp, err := svc.DescribeImageBuilders(&appstream.DescribeImageBuildersInput{
-                Names:  aws.StringSlice([]string{*StartImageBuilderInputOptions.Name}),
-            })
+	if err != nil {
+		log.Printf("[ERROR] Error describing Appstream Image Builder: %s", err)
+		return err
+	}
 
-            if err != nil {
-                log.Printf("[ERROR] Error describing Appstream Image Builder: %s", err)
-                return err
-            }
+	state := resp.ImageBuilders[0].State
 
-            curr_state := resp.ImageBuilders[0].State
-            if aws.StringValue(curr_state) == desired_state{
-                break
-            }
-            if aws.StringValue(curr_state) != desired_state {
-                time.Sleep(20 * time.Second)
-                continue
-            }
+	if aws.StringValue(state) == "RUNNING" {
+		resp, err := svc.StopImageBuilder(&appstream.StopImageBuilderInput{
+			Name: aws.String(d.Id()),
+		})
 
-        }
-    }
+		if err != nil {
+			log.Printf("[ERROR] Error stopping Appstream Image Builder: %s", err)
+			return err
+		}
 
-    d.Partial(false)
-    return resourceAppstreamImageBuilderRead(d, meta)
+		log.Printf("[DEBUG] %s", resp)
 
-}
+		for {
 
-func resourceAppstreamImageBuilderDelete(d *schema.ResourceData, meta interface{}) error {
-	svc := meta.(*AWSClient).appstreamconn
+			resp, err := svc.DescribeImageBuilders(&appstream.DescribeImageBuildersInput{
+				Names: aws.StringSlice([]string{ImageBuilderName}),
+			})
 
-    ImageBuilderName := d.Id()
+			if err != nil {
+				log.Printf("[ERROR] Error describing Appstream Image Builder: %s", err)
+				return err
+			}
 
-    resp, err := svc.DescribeImageBuilders(&appstream.DescribeImageBuildersInput{
-        Names:  aws.StringSlice([]string{ImageBuilderName}),
-    })
+			state := resp.ImageBuilders[0].State
+			if aws.StringValue(state) == "STOPPED" {
+				break
+			}
+			if aws.StringValue(state) != "STOPPED" {
+				log.Printf("[DEBUG] Image Builder not running")
+				time.Sleep(20 * time.Second)
+				continue
+			}
 
-    if err != nil {
-	log.Printf("[ERROR] Error describing Appstream Image Builder: %s", err)
-	return err
-    }
+		}
+	}
 
-    state := resp.ImageBuilders[0].State
+	del, err := svc.DeleteImageBuilder(&appstream.DeleteImageBuilderInput{
+		Name: aws.String(d.Id()),
+	})
+	if err != nil {
+		log.Printf("[ERROR] Error deleting Appstream Image Builder: %s", err)
+		return err
+	}
+	log.Printf("[DEBUG] %s", del)
 
-    if aws.StringValue(state) == "RUNNING" {
-        resp, err := svc.StopImageBuilder(&appstream.StopImageBuilderInput{
-            Name: aws.String(d.Id()),
-        })
+	return nil
+}
 
-        if err != nil {
-	    log.Printf("[ERROR] Error stopping Appstream Image Builder: %s", err)
-	    return err
-        }
+// describeImageBuilder returns the named image builder, or nil when it does not exist.
+func describeImageBuilder(svc *appstream.AppStream, name string) (*appstream.ImageBuilder, error) {
+	resp, err := svc.DescribeImageBuilders(&appstream.DescribeImageBuildersInput{
+		Names: aws.StringSlice([]string{name}),
+	})
+	if isAWSErr(err, appstream.ErrCodeResourceNotFoundException, "") {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("[ERROR] Error describing Appstream Image Builder: %s", err)
+		return nil, err
+	}
+	for _, v := range resp.ImageBuilders {
+		if aws.StringValue(v.Name) == name {
+			return v, nil
+		}
+	}
+	return nil, nil
+}
 
-        log.Printf("[DEBUG] %s", resp)
+// waitForAppstreamImageBuilderStable waits until the image builder is RUNNING, STOPPED or
+// FAILED, so a builder still pending, starting or stopping is not acted on mid-transition.
+func waitForAppstreamImageBuilderStable(svc *appstream.AppStream, name string, timeout time.Duration) (*appstream.ImageBuilder, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		builder, err := describeImageBuilder(svc, name)
+		if err != nil {
+			return nil, err
+		}
+		if builder == nil {
+			return nil, fmt.Errorf("Appstream Image Builder (%s) not found", name)
+		}
 
-        for {
+		switch state := aws.StringValue(builder.State); state {
+		case appstream.ImageBuilderStateRunning, appstream.ImageBuilderStateStopped:
+			return builder, nil
+		case appstream.ImageBuilderStateFailed:
+			return nil, fmt.Errorf("Appstream Image Builder (%s) failed: %s", name, imageBuilderStateChangeReason(builder))
+		default:
+			if time.Now().After(deadline) {
+				return nil, fmt.Errorf("timeout waiting for Appstream Image Builder (%s), last state %s", name, state)
+			}
+			log.Printf("[DEBUG] Appstream Image Builder (%s) is %s, waiting", name, state)
+			time.Sleep(20 * time.Second)
+		}
+	}
+}
 
-            resp, err := svc.DescribeImageBuilders(&appstream.DescribeImageBuildersInput{
-                Names:  aws.StringSlice([]string{ImageBuilderName}),
-            })
+// waitForAppstreamImageBuilderTransition waits until the image builder has left the from
+// state and settled again. Start and stop return before the state changes, so a builder
+// read right after them can still report the state it is leaving.
+func waitForAppstreamImageBuilderTransition(svc *appstream.AppStream, name string, from string, timeout time.Duration) (*appstream.ImageBuilder, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		builder, err := describeImageBuilder(svc, name)
+		if err != nil {
+			return nil, err
+		}
+		if builder == nil {
+			return nil, fmt.Errorf("Appstream Image Builder (%s) not found", name)
+		}
+
+		if state := aws.StringValue(builder.State); state != from {
+			return waitForAppstreamImageBuilderStable(svc, name, time.Until(deadline))
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timeout waiting for Appstream Image Builder (%s) to leave %s", name, from)
+		}
+		log.Printf("[DEBUG] Appstream Image Builder (%s) is still %s, waiting", name, from)
+		time.Sleep(20 * time.Second)
+	}
+}
 
-            if err != nil {
-                log.Printf("[ERROR] Error describing Appstream Image Builder: %s", err)
-                return err
-            }
+// stableImageBuilderState returns the observed state when the image builder is RUNNING or
+// STOPPED and desired otherwise, so a builder caught mid-transition is not reported as drift.
+func stableImageBuilderState(observed string, desired string) string {
+	switch observed {
+	case appstream.ImageBuilderStateRunning, appstream.ImageBuilderStateStopped:
+		return observed
+	}
+	return desired
+}
 
-            state := resp.ImageBuilders[0].State
-            if aws.StringValue(state) == "STOPPED" {
-                break
-            }
-            if aws.StringValue(state) != "STOPPED" {
-                log.Printf("[DEBUG] Image Builder not running")
-                time.Sleep(20 * time.Second)
-                continue
-            }
+// reconcileAppstreamImageBuilderState lets a transition in progress finish, then starts or
+// stops the image builder if it did not end up in the desired state.
+func reconcileAppstreamImageBuilderState(svc *appstream.AppStream, name string, desired string, timeout time.Duration) error {
+	builder, err := waitForAppstreamImageBuilderStable(svc, name, timeout)
+	if err != nil {
+		return err
+	}
+	if aws.StringValue(builder.State) == desired {
+		return nil
+	}
 
-        }
-    }
+	switch desired {
+	case appstream.ImageBuilderStateRunning:
+		resp, err := svc.StartImageBuilder(&appstream.StartImageBuilderInput{
+			Name: aws.String(name),
+		})
+		if err != nil {
+			log.Printf("[ERROR] Error starting Appstream Image Builder: %s", err)
+			return err
+		}
+		log.Printf("[DEBUG] %s", resp)
+	case appstream.ImageBuilderStateStopped:
+		resp, err := svc.StopImageBuilder(&appstream.StopImageBuilderInput{
+			Name: aws.String(name),
+		})
+		if err != nil {
+			log.Printf("[ERROR] Error stopping Appstream Image Builder: %s", err)
+			return err
+		}
+		log.Printf("[DEBUG] %s", resp)
+	}
 
+	builder, err = waitForAppstreamImageBuilderTransition(svc, name, aws.StringValue(builder.State), timeout)
+	if err != nil {
+		return err
+	}
+	if aws.StringValue(builder.State) != desired {
+		return fmt.Errorf("Appstream Image Builder (%s) is %s instead of %s", name, aws.StringValue(builder.State), desired)
+	}
+	return nil
+}
 
-    del, err := svc.DeleteImageBuilder(&appstream.DeleteImageBuilderInput{
-        Name: aws.String(d.Id()),
-    })
-    if err != nil {
-        log.Printf("[ERROR] Error deleting Appstream Image Builder: %s", err)
-        return err
-    }
-    log.Printf("[DEBUG] %s", del)
+func imageBuilderStateChangeReason(builder *appstream.ImageBuilder) string {
+	if builder.StateChangeReason == nil {
+		return "no reason given"
+	}
+	return fmt.Sprintf("%s: %s", aws.StringValue(builder.StateChangeReason.Code), aws.StringValue(builder.StateChangeReason.Message))
+}
 
-    return nil
+// suppressLatestAgentVersion keeps a builder created with the LATEST agent from being
+// replaced once Read reports the concrete version the API resolved it to.
+func suppressLatestAgentVersion(k, old, new string, d *schema.ResourceData) bool {
+	return new == "LATEST" && old != ""
 }
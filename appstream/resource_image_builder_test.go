@@ -0,0 +1,164 @@
+package appstream
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/appstream"
+	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+)
+
+func TestResourceAppstreamImageBuilder_replacement(t *testing.T) {
+	testCheckSchemaReplacement(t, resourceAppstreamImageBuilder(),
+		[]string{
+			"access_endpoints",
+			"appstream_agent_version",
+			"description",
+			"display_name",
+			"domain_info",
+			"enable_default_internet_access",
+			"image_arn",
+			"instance_type",
+			"name",
+			"vpc_config",
+		},
+		[]string{
+			"creation_token",
+			"desired_state",
+			"tags",
+		},
+	)
+}
+
+func TestResourceAppstreamImageBuilder_diff(t *testing.T) {
+	state := map[string]string{
+		"name":                              "test-image-builder",
+		"image_arn":                         "arn:aws:appstream:eu-west-1:123456789012:image/base",
+		"instance_type":                     "stream.standard.medium",
+		"desired_state":                     "RUNNING",
+		"state":                             "RUNNING",
+		"vpc_config.#":                      "1",
+		"vpc_config.0.security_group_ids.#": "0",
+		"vpc_config.0.subnet_ids.#":         "1",
+		"vpc_config.0.subnet_ids.1234":      "subnet-7a5f4b51",
+	}
+	config := map[string]interface{}{
+		"name":          "test-image-builder",
+		"image_arn":     "arn:aws:appstream:eu-west-1:123456789012:image/base",
+		"instance_type": "stream.standard.large",
+		"desired_state": "STOPPED",
+		"vpc_config": []interface{}{map[string]interface{}{
+			"subnet_ids": []interface{}{"subnet-7a5f1231"},
+		}},
+	}
+
+	diff := testResourceDiff(t, resourceAppstreamImageBuilder(), state, config)
+	if diff == nil {
+		t.Fatal("expected a diff")
+	}
+	for _, k := range []string{"desired_state", "instance_type"} {
+		if diff.Attributes[k] == nil {
+			t.Errorf("expected a diff on %s", k)
+		}
+	}
+	if !diff.Attributes["instance_type"].RequiresNew {
+		t.Error("expected instance_type to force replacement")
+	}
+	if diff.Attributes["desired_state"].RequiresNew {
+		t.Error("expected desired_state to be updated in place")
+	}
+}
+
+func TestResourceAppstreamImageBuilder_import(t *testing.T) {
+	server := newTestAppstreamServer(t)
+	server.addImageBuilder(&appstream.ImageBuilder{
+		Name:         aws.String("test-image-builder"),
+		ImageArn:     aws.String("arn:aws:appstream:eu-west-1:123456789012:image/base"),
+		InstanceType: aws.String("stream.standard.medium"),
+		State:        aws.String(appstream.ImageBuilderStateStopped),
+	})
+	client := server.client(t)
+
+	state := testImportState(t, resourceAppstreamImageBuilder(), "test-image-builder", client)
+	for k, v := range map[string]string{
+		"name":          "test-image-builder",
+		"image_arn":     "arn:aws:appstream:eu-west-1:123456789012:image/base",
+		"instance_type": "stream.standard.medium",
+		"state":         appstream.ImageBuilderStateStopped,
+	} {
+		if state.Attributes[k] != v {
+			t.Errorf("expected %s %q, got %q", k, v, state.Attributes[k])
+		}
+	}
+}
+
+func TestResourceAppstreamImageBuilder_adopt(t *testing.T) {
+	// tag is the creation token the existing builder was tagged with, token the configured one.
+	cases := map[string]struct {
+		tag       string
+		token     string
+		expectErr bool
+	}{
+		"same creation token":              {"ci-desktop", "ci-desktop", false},
+		"other creation token":             {"ci-desktop", "ci-kiosk", true},
+		"no creation token":                {"ci-desktop", "", true},
+		"default creation token":           {"test-image-builder", "", false},
+		"not created by the provider":      {"", "", true},
+		"not created by the provider, set": {"", "ci-desktop", true},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			server := newTestAppstreamServer(t)
+			server.addImageBuilder(&appstream.ImageBuilder{
+				Name:         aws.String("test-image-builder"),
+				ImageArn:     aws.String("arn:aws:appstream:eu-west-1:123456789012:image/base"),
+				InstanceType: aws.String("stream.standard.medium"),
+				State:        aws.String(appstream.ImageBuilderStateStopped),
+			})
+			if tc.tag != "" {
+				server.tags["arn:aws:appstream:eu-west-1:123456789012:image-builder/test-image-builder"][managedByTagKey] = aws.String(tc.tag)
+			}
+			client := server.client(t)
+
+			raw := map[string]interface{}{
+				"name":          "test-image-builder",
+				"image_arn":     "arn:aws:appstream:eu-west-1:123456789012:image/base",
+				"instance_type": "stream.standard.medium",
+				"desired_state": appstream.ImageBuilderStateRunning,
+			}
+			if tc.token != "" {
+				raw["creation_token"] = tc.token
+			}
+			r := resourceAppstreamImageBuilder()
+			diff, err := r.Diff(nil, terraform.NewResourceConfigRaw(raw), client)
+			if err != nil {
+				t.Fatalf("error planning: %s", err)
+			}
+
+			state, err := r.Apply(nil, diff, client)
+			if tc.expectErr {
+				if err == nil || !strings.Contains(err.Error(), "already exists") {
+					t.Fatalf("expected an already exists error, got %v", err)
+				}
+				if server.calls["StartImageBuilder"] != 0 {
+					t.Error("expected the existing builder to be left alone")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error adopting: %s", err)
+			}
+			if state.ID != "test-image-builder" || state.Attributes["creation_token"] != tc.tag {
+				t.Errorf("expected the builder to be adopted with its creation token, got %s: %v", state.ID, state.Attributes)
+			}
+			if state.Attributes["state"] != appstream.ImageBuilderStateRunning {
+				t.Errorf("expected the adopted builder to be started, got %s", state.Attributes["state"])
+			}
+			if server.calls["CreateImageBuilder"] != 0 {
+				t.Errorf("expected no CreateImageBuilder call, got %d", server.calls["CreateImageBuilder"])
+			}
+		})
+	}
+}
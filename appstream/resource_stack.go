@@ -1,12 +1,14 @@
 package appstream
 
 import (
+	"fmt"
 	"log"
-	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/appstream"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
 )
 
 func resourceAppstreamStack() *schema.Resource {
@@ -19,30 +21,98 @@ func resourceAppstreamStack() *schema.Resource {
 			State: schema.ImportStatePassthrough,
 		},
 
+		CustomizeDiff: customdiff.Sequence(
+			resourceAppstreamStackCustomizeDiffStorageConnectors,
+			resourceAppstreamStackCustomizeDiffUserSettings,
+			resourceAppstreamStackCustomizeDiffSettingsGroup,
+		),
+
 		Schema: map[string]*schema.Schema{
-			"description": {
-				Type:     schema.TypeString,
+			"access_endpoints": accessEndpointsSchema(false),
+
+			// Confirms a settings_group change, which orphans the settings users saved under
+			// the previous group. Only read at plan time, never sent to the API.
+			"allow_settings_group_change": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+
+			"application_settings": {
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"enabled": {
+							Type:     schema.TypeBool,
+							Required: true,
+						},
+						"s3_bucket_name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"settings_group": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Computed:     true,
+							ValidateFunc: validation.StringLenBetween(0, 100),
+						},
+					},
+				},
+			},
+
+			"deletion_protection": {
+				Type:     schema.TypeBool,
 				Optional: true,
+				Default:  false,
+			},
+
+			"description": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringLenBetween(0, 256),
 			},
 
 			"display_name": {
-				Type:     schema.TypeString,
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringLenBetween(0, 100),
+			},
+
+			"embed_host_domains": {
+				Type:     schema.TypeSet,
 				Optional: true,
+				MaxItems: 20,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validateDomainName,
+				},
 			},
 
 			"feedback_url": {
-				Type:     schema.TypeString,
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateAppstreamURL,
+			},
+
+			"force_destroy": {
+				Type:     schema.TypeBool,
 				Optional: true,
+				Default:  false,
 			},
 
 			"name": {
-				Type:     schema.TypeString,
-				Required: true,
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateAppstreamName,
 			},
 
 			"redirect_url": {
-				Type:     schema.TypeString,
-				Optional: true,
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateAppstreamURL,
 			},
 
 			"storage_connectors": {
@@ -51,12 +121,42 @@ func resourceAppstreamStack() *schema.Resource {
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"connector_type": {
-							Type:     schema.TypeString,
-							Required: true,
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validation.StringInSlice(appstream.StorageConnectorType_Values(), false),
+						},
+						"domains": {
+							Type:     schema.TypeSet,
+							Optional: true,
+							MaxItems: 50,
+							Elem: &schema.Schema{
+								Type:         schema.TypeString,
+								ValidateFunc: validation.StringLenBetween(1, 64),
+							},
+						},
+						"resource_identifier": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validation.StringLenBetween(1, 2048),
+						},
+					},
+				},
+			},
+			"streaming_experience_settings": {
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"preferred_protocol": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validation.StringInSlice(appstream.PreferredProtocol_Values(), false),
 						},
 					},
 				},
 			},
+
 			"tags": {
 				Type:     schema.TypeMap,
 				Optional: true,
@@ -67,13 +167,20 @@ func resourceAppstreamStack() *schema.Resource {
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"action": {
-							Type:     schema.TypeString,
-							Required: true,
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validation.StringInSlice(appstream.Action_Values(), false),
 						},
 						"enabled": {
 							Type:     schema.TypeBool,
 							Required: true,
 						},
+						// Only applies to the clipboard actions.
+						"maximum_length": {
+							Type:         schema.TypeInt,
+							Optional:     true,
+							ValidateFunc: validation.IntBetween(1, clipboardDefaultMaximumLength),
+						},
 					},
 				},
 			},
@@ -91,6 +198,14 @@ func resourceAppstreamStackCreate(d *schema.ResourceData, meta interface{}) erro
 		CreateStackInputOpts.Name = aws.String(v.(string))
 	}
 
+	if v, ok := d.GetOk("access_endpoints"); ok {
+		CreateStackInputOpts.AccessEndpoints = expandAccessEndpoints(v.(*schema.Set).List())
+	}
+
+	if v, ok := d.GetOk("application_settings"); ok {
+		CreateStackInputOpts.ApplicationSettings = expandApplicationSettings(v.([]interface{}))
+	}
+
 	if v, ok := d.GetOk("description"); ok {
 		CreateStackInputOpts.Description = aws.String(v.(string))
 	}
@@ -99,6 +214,10 @@ func resourceAppstreamStackCreate(d *schema.ResourceData, meta interface{}) erro
 		CreateStackInputOpts.DisplayName = aws.String(v.(string))
 	}
 
+	if v, ok := d.GetOk("embed_host_domains"); ok {
+		CreateStackInputOpts.EmbedHostDomains = expandStringSet(v.(*schema.Set))
+	}
+
 	if v, ok := d.GetOk("feedback_url"); ok {
 		CreateStackInputOpts.FeedbackURL = aws.String(v.(string))
 	}
@@ -112,11 +231,21 @@ func resourceAppstreamStackCreate(d *schema.ResourceData, meta interface{}) erro
 		CreateStackInputOpts.StorageConnectors = expandStorageConnectorConfigs(storageConnectorConfigs)
 	}
 
+	if v, ok := d.GetOk("streaming_experience_settings"); ok {
+		CreateStackInputOpts.StreamingExperienceSettings = expandStreamingExperienceSettings(v.([]interface{}))
+	}
+
 	if v, ok := d.GetOk("user_settings"); ok {
 		userSettingConfigs := v.(*schema.Set).List()
 		CreateStackInputOpts.UserSettings = expandUserSettingConfigs(userSettingConfigs)
 	}
 
+	tags := New(d.Get("tags").(map[string]interface{})).IgnoreAWS().IgnoreProvider()
+	tags = tags.Merge(deletionProtectionTags(d.Get("deletion_protection").(bool)))
+	if len(tags) > 0 {
+		CreateStackInputOpts.Tags = Tags(tags)
+	}
+
 	log.Printf("[DEBUG] Run configuration: %s", CreateStackInputOpts)
 
 	resp, err := svc.CreateStack(CreateStackInputOpts)
@@ -126,44 +255,10 @@ func resourceAppstreamStackCreate(d *schema.ResourceData, meta interface{}) erro
 		return err
 	}
 	log.Printf("[DEBUG] Appstream stack created %s ", resp)
-	time.Sleep(2 * time.Second)
-	if v, ok := d.GetOk("tags"); ok {
-
-		data_tags := v.(map[string]interface{})
-		attr := make(map[string]string)
-		for k, v := range data_tags {
-			attr[k] = v.(string)
-		}
 
-		tags := aws.StringMap(attr)
-
-		stack_name := aws.StringValue(CreateStackInputOpts.Name)
-		get, err := svc.DescribeStacks(&appstream.DescribeStacksInput{
-			Names: aws.StringSlice([]string{stack_name}),
-		})
-		if err != nil {
-			log.Printf("[ERROR] Error describing Appstream Stack: %s", err)
-			return err
-		}
-		if get.Stacks == nil {
-			log.Printf("[DEBUG] Apsstream Stack (%s) not found", d.Id())
-		}
-
-		stackArn := get.Stacks[0].Arn
-
-		tag, err := svc.TagResource(&appstream.TagResourceInput{
-			ResourceArn: stackArn,
-			Tags:        tags,
-		})
-		if err != nil {
-			log.Printf("[ERROR] Error tagging Appstream Stack: %s", err)
-			return err
-		}
-		log.Printf("[DEBUG] %s", tag)
-	}
-
-	log.Printf("[DEBUG] %s", resp)
-	d.SetId(*CreateStackInputOpts.Name)
+	// CreateStack carries the tags, so the stack is complete once it exists. A failed read
+	// back is returned with the ID set, so the stack stays in state.
+	d.SetId(aws.StringValue(CreateStackInputOpts.Name))
 
 	return resourceAppstreamStackRead(d, meta)
 }
@@ -172,159 +267,307 @@ func resourceAppstreamStackRead(d *schema.ResourceData, meta interface{}) error
 
 	svc := meta.(*AWSClient).appstreamconn
 
-	resp, err := svc.DescribeStacks(&appstream.DescribeStacksInput{})
+	v, err := describeStack(svc, d.Id())
 	if err != nil {
-		log.Printf("[ERROR] Error describing stacks: %s", err)
 		return err
 	}
+	if v == nil {
+		log.Printf("[WARN] Appstream Stack (%s) not found, removing from state", d.Id())
+		d.SetId("")
+		return nil
+	}
 
-	for _, v := range resp.Stacks {
-
-		if aws.StringValue(v.Name) == d.Get("name") {
-			d.Set("name", v.Name)
-			d.Set("description", v.Description)
-			d.Set("display_name", v.DisplayName)
-			d.Set("feedback_url", v.FeedbackURL)
-			d.Set("redirect_url", v.RedirectURL)
-
-			attr := map[string]interface{}{}
-			res := make([]map[string]interface{}, 0)
+	d.Set("name", v.Name)
+	d.Set("description", v.Description)
+	d.Set("display_name", v.DisplayName)
+	d.Set("feedback_url", v.FeedbackURL)
+	if err := d.Set("embed_host_domains", flattenStringList(v.EmbedHostDomains)); err != nil {
+		log.Printf("[ERROR] Error setting embed host domains: %s", err)
+		return err
+	}
+	d.Set("redirect_url", v.RedirectURL)
 
-			sc := v.StorageConnectors
-			if len(sc) > 0 {
-				attr["connector_type"] = aws.StringValue(sc[0].ConnectorType)
-				res = append(res, attr)
-			}
+	if err := d.Set("access_endpoints", flattenAccessEndpoints(v.AccessEndpoints)); err != nil {
+		log.Printf("[ERROR] Error setting access endpoints: %s", err)
+		return err
+	}
 
-			if len(res) > 0 {
-				if err := d.Set("storage_connectors", res); err != nil {
-					log.Printf("[ERROR] Error setting storage connector: %s", err)
-				}
-			}
+	if v.ApplicationSettings != nil && (aws.BoolValue(v.ApplicationSettings.Enabled) || len(d.Get("application_settings").([]interface{})) > 0) {
+		if err := d.Set("application_settings", flattenApplicationSettings(v.ApplicationSettings)); err != nil {
+			log.Printf("[ERROR] Error setting application settings: %s", err)
+			return err
+		}
+	} else {
+		d.Set("application_settings", nil)
+	}
 
-			us_list := v.UserSettings
-			us_res := make([]map[string]interface{}, 0)
+	if err := d.Set("storage_connectors", flattenStorageConnectors(v.StorageConnectors, d.Get("storage_connectors").(*schema.Set).List())); err != nil {
+		log.Printf("[ERROR] Error setting storage connectors: %s", err)
+		return err
+	}
 
-			for _, us := range us_list {
-				us_attr := map[string]interface{}{}
-				us_attr["action"] = aws.StringValue(us.Action)
-				us_attr["enabled"] = (aws.StringValue(us.Permission) == appstream.PermissionEnabled)
-				us_res = append(us_res, us_attr)
-			}
+	if err := d.Set("streaming_experience_settings", flattenStreamingExperienceSettings(v.StreamingExperienceSettings)); err != nil {
+		log.Printf("[ERROR] Error setting streaming experience settings: %s", err)
+		return err
+	}
 
-			if len(us_res) > 0 {
-				if err := d.Set("user_settings", us_res); err != nil {
-					log.Printf("[ERROR] Error setting user settings: %s", err)
-					return err
-				}
-			}
+	if err := d.Set("user_settings", flattenUserSettings(v.UserSettings, d.Get("user_settings").(*schema.Set).List())); err != nil {
+		log.Printf("[ERROR] Error setting user settings: %s", err)
+		return err
+	}
 
-			log.Printf("Dump of settings %+v", d.Get("user_settings"))
+	tg, err := svc.ListTagsForResource(&appstream.ListTagsForResourceInput{
+		ResourceArn: v.Arn,
+	})
+	if err != nil {
+		log.Printf("[ERROR] Error listing stack tags: %s", err)
+		return err
+	}
 
-			tg, err := svc.ListTagsForResource(&appstream.ListTagsForResourceInput{
-				ResourceArn: v.Arn,
-			})
-			if err != nil {
-				log.Printf("[ERROR] Error listing stack tags: %s", err)
-				return err
-			}
-			if tg.Tags == nil {
-				log.Printf("[DEBUG] Apsstream Stack tags (%s) not found", d.Id())
-				return nil
-			}
+	d.Set("deletion_protection", aws.StringValue(tg.Tags[deletionProtectionTagKey]) == "true")
 
-			if len(tg.Tags) > 0 {
-				tags_attr := make(map[string]string)
-				tags := tg.Tags
-				for k, v := range tags {
-					tags_attr[k] = aws.StringValue(v)
-				}
-				d.Set("tags", tags_attr)
-			}
-			return nil
-		}
+	if err := d.Set("tags", New(tg.Tags).IgnoreAWS().IgnoreProvider().Map()); err != nil {
+		log.Printf("[ERROR] Error setting stack tags: %s", err)
+		return err
 	}
-
-	d.SetId("")
 	return nil
-
 }
 
 func resourceAppstreamStackUpdate(d *schema.ResourceData, meta interface{}) error {
 	svc := meta.(*AWSClient).appstreamconn
 
-	UpdateStackInputOpts := &appstream.UpdateStackInput{}
+	UpdateStackInputOpts := &appstream.UpdateStackInput{
+		Name: aws.String(d.Id()),
+	}
 
+	// Partial mode stays on if a step fails, so state only records the steps that were
+	// applied and the next plan retries the rest.
 	d.Partial(true)
 
-	if v, ok := d.GetOk("name"); ok {
-		UpdateStackInputOpts.Name = aws.String(v.(string))
+	if d.HasChange("access_endpoints") {
+		log.Printf("[DEBUG] Modify appstream stack")
+		if v := d.Get("access_endpoints").(*schema.Set); v.Len() > 0 {
+			UpdateStackInputOpts.AccessEndpoints = expandAccessEndpoints(v.List())
+		} else {
+			UpdateStackInputOpts.AttributesToDelete = append(UpdateStackInputOpts.AttributesToDelete, aws.String(appstream.StackAttributeAccessEndpoints))
+		}
+	}
+
+	if d.HasChange("application_settings") {
+		log.Printf("[DEBUG] Modify appstream stack")
+		if v := d.Get("application_settings").([]interface{}); len(v) > 0 {
+			UpdateStackInputOpts.ApplicationSettings = expandApplicationSettings(v)
+		} else {
+			UpdateStackInputOpts.ApplicationSettings = &appstream.ApplicationSettings{
+				Enabled: aws.Bool(false),
+			}
+		}
 	}
 
 	if d.HasChange("description") {
-		d.SetPartial("description")
 		log.Printf("[DEBUG] Modify appstream stack")
 		description := d.Get("description").(string)
 		UpdateStackInputOpts.Description = aws.String(description)
 	}
 
 	if d.HasChange("display_name") {
-		d.SetPartial("display_name")
 		log.Printf("[DEBUG] Modify appstream stack")
 		displayname := d.Get("display_name").(string)
 		UpdateStackInputOpts.DisplayName = aws.String(displayname)
 	}
 
+	if d.HasChange("embed_host_domains") {
+		log.Printf("[DEBUG] Modify appstream stack")
+		if v := d.Get("embed_host_domains").(*schema.Set); v.Len() > 0 {
+			UpdateStackInputOpts.EmbedHostDomains = expandStringSet(v)
+		} else {
+			UpdateStackInputOpts.AttributesToDelete = append(UpdateStackInputOpts.AttributesToDelete, aws.String(appstream.StackAttributeEmbedHostDomains))
+		}
+	}
+
 	if d.HasChange("feedback_url") {
-		d.SetPartial("feedback_url")
 		log.Printf("[DEBUG] Modify appstream stack")
-		feedbackurl := d.Get("feedback_url").(string)
-		UpdateStackInputOpts.FeedbackURL = aws.String(feedbackurl)
+		if feedbackurl := d.Get("feedback_url").(string); feedbackurl != "" {
+			UpdateStackInputOpts.FeedbackURL = aws.String(feedbackurl)
+		} else {
+			UpdateStackInputOpts.AttributesToDelete = append(UpdateStackInputOpts.AttributesToDelete, aws.String(appstream.StackAttributeFeedbackUrl))
+		}
 	}
 
 	if d.HasChange("redirect_url") {
-		d.SetPartial("redirect_url")
 		log.Printf("[DEBUG] Modify appstream stack")
-		redirecturl := d.Get("redirect_url").(string)
-		UpdateStackInputOpts.RedirectURL = aws.String(redirecturl)
+		if redirecturl := d.Get("redirect_url").(string); redirecturl != "" {
+			UpdateStackInputOpts.RedirectURL = aws.String(redirecturl)
+		} else {
+			UpdateStackInputOpts.AttributesToDelete = append(UpdateStackInputOpts.AttributesToDelete, aws.String(appstream.StackAttributeRedirectUrl))
+		}
 	}
 
-	if d.HasChange("user_settings") {
+	if d.HasChange("storage_connectors") {
 		log.Printf("[DEBUG] Modify appstream stack")
-		userSettingConfigs := d.Get("user_settings").(*schema.Set).List()
-		UpdateStackInputOpts.UserSettings = expandUserSettingConfigs(userSettingConfigs)
+		o, n := d.GetChange("storage_connectors")
+		if n.(*schema.Set).Len() > 0 {
+			UpdateStackInputOpts.StorageConnectors = expandStorageConnectorConfigs(n.(*schema.Set).List())
+			// Connectors left out of the list are kept by the API, they have to be deleted by type.
+			for _, t := range removedStorageConnectorTypes(o.(*schema.Set).List(), n.(*schema.Set).List()) {
+				attr, err := storageConnectorStackAttribute(t)
+				if err != nil {
+					return err
+				}
+				UpdateStackInputOpts.AttributesToDelete = append(UpdateStackInputOpts.AttributesToDelete, aws.String(attr))
+			}
+		} else {
+			UpdateStackInputOpts.AttributesToDelete = append(UpdateStackInputOpts.AttributesToDelete, aws.String(appstream.StackAttributeStorageConnectors))
+		}
 	}
 
-	resp, err := svc.UpdateStack(UpdateStackInputOpts)
-
-	if err != nil {
-		log.Printf("[ERROR] Error updating Appstream Stack: %s", err)
-		return err
+	if d.HasChange("streaming_experience_settings") {
+		log.Printf("[DEBUG] Modify appstream stack")
+		if settings := expandStreamingExperienceSettings(d.Get("streaming_experience_settings").([]interface{})); settings != nil {
+			UpdateStackInputOpts.StreamingExperienceSettings = settings
+		} else {
+			UpdateStackInputOpts.AttributesToDelete = append(UpdateStackInputOpts.AttributesToDelete, aws.String(appstream.StackAttributeStreamingExperienceSettings))
+		}
 	}
-	log.Printf("[DEBUG] %s", resp)
 
+	if d.HasChange("user_settings") {
+		log.Printf("[DEBUG] Modify appstream stack")
+		o, n := d.GetChange("user_settings")
+		userSettings := expandUserSettingConfigs(n.(*schema.Set).List())
+		// A setting dropped from the configuration goes back to the API default instead of
+		// keeping whatever it was last set to.
+		for _, action := range removedUserSettingActions(o.(*schema.Set).List(), n.(*schema.Set).List()) {
+			userSettings = append(userSettings, &appstream.UserSetting{
+				Action:     aws.String(action),
+				Permission: aws.String(defaultUserSettingPermission(action)),
+			})
+		}
+		UpdateStackInputOpts.UserSettings = userSettings
+	}
 
-	if d.HasChange("tags") {
-		arn := aws.StringValue(resp.Stack.Arn)
+	// Everything UpdateStack can change goes out in a single call, which is skipped when
+	// only tags or provider-side arguments changed.
+	stackKeys := []string{"access_endpoints", "application_settings", "description", "display_name",
+		"embed_host_domains", "feedback_url", "redirect_url", "storage_connectors",
+		"streaming_experience_settings", "user_settings"}
+	if d.HasChanges(stackKeys...) {
+		log.Printf("[DEBUG] Run configuration: %s", UpdateStackInputOpts)
+		resp, err := svc.UpdateStack(UpdateStackInputOpts)
+		if err != nil {
+			log.Printf("[ERROR] Error updating Appstream Stack: %s", err)
+			return err
+		}
+		log.Printf("[DEBUG] %s", resp)
+	}
+	for _, k := range stackKeys {
+		d.SetPartial(k)
+	}
+	d.SetPartial("allow_settings_group_change")
+	d.SetPartial("force_destroy")
 
-		o, n := d.GetChange("tags")
-		if err := UpdateTags(svc, arn, o, n); err != nil {
+	if d.HasChanges("tags", "deletion_protection") {
+		stack, err := describeStack(svc, d.Id())
+		if err != nil {
 			return err
 		}
+		if stack == nil {
+			return fmt.Errorf("Appstream Stack (%s) not found", d.Id())
+		}
+		arn := aws.StringValue(stack.Arn)
+
+		if d.HasChange("tags") {
+			o, n := d.GetChange("tags")
+			if err := UpdateTags(svc, arn, o, n); err != nil {
+				return err
+			}
+		}
+		d.SetPartial("tags")
+
+		if d.HasChange("deletion_protection") {
+			if err := updateDeletionProtection(svc, arn, d.Get("deletion_protection").(bool)); err != nil {
+				return err
+			}
+		}
+		d.SetPartial("deletion_protection")
 	}
 
 	d.Partial(false)
-	return nil
-
+	return resourceAppstreamStackRead(d, meta)
 }
 
 func resourceAppstreamStackDelete(d *schema.ResourceData, meta interface{}) error {
 
 	svc := meta.(*AWSClient).appstreamconn
 
+	if d.Get("deletion_protection").(bool) {
+		return fmt.Errorf("Appstream Stack (%s) has deletion_protection enabled, set it to false and apply before destroying", d.Id())
+	}
+
+	stack, err := describeStack(svc, d.Id())
+	if err != nil {
+		return err
+	}
+	if stack == nil {
+		return nil
+	}
+	if err := checkDeletionProtection(svc, aws.StringValue(stack.Arn)); err != nil {
+		return err
+	}
+
+	fleets, err := listAssociatedFleets(svc, d.Id())
+	if err != nil {
+		return err
+	}
+	users, err := describeUserStackAssociations(svc, d.Id())
+	if err != nil {
+		return err
+	}
+
+	if len(fleets) > 0 || len(users) > 0 {
+		if !d.Get("force_destroy").(bool) {
+			return fmt.Errorf("Appstream Stack (%s) is still associated with %d fleet(s) %v and %d user(s), remove the associations or set force_destroy to true",
+				d.Id(), len(fleets), fleets, len(users))
+		}
+
+		for _, fleet := range fleets {
+			dis, err := svc.DisassociateFleet(&appstream.DisassociateFleetInput{
+				FleetName: aws.String(fleet),
+				StackName: aws.String(d.Id()),
+			})
+			if err != nil && !isAWSErr(err, appstream.ErrCodeResourceNotFoundException, "") {
+				log.Printf("[ERROR] Error disassociating fleet %s from Appstream Stack: %s", fleet, err)
+				return err
+			}
+			log.Printf("[DEBUG] %s", dis)
+		}
+
+		// BatchDisassociateUserStack accepts at most 25 associations per call.
+		for i := 0; i < len(users); i += 25 {
+			end := i + 25
+			if end > len(users) {
+				end = len(users)
+			}
+			dis, err := svc.BatchDisassociateUserStack(&appstream.BatchDisassociateUserStackInput{
+				UserStackAssociations: users[i:end],
+			})
+			if err != nil {
+				log.Printf("[ERROR] Error disassociating users from Appstream Stack: %s", err)
+				return err
+			}
+			if len(dis.Errors) > 0 {
+				return fmt.Errorf("error disassociating users from Appstream Stack (%s): %s: %s", d.Id(),
+					aws.StringValue(dis.Errors[0].ErrorCode), aws.StringValue(dis.Errors[0].ErrorMessage))
+			}
+			log.Printf("[DEBUG] %s", dis)
+		}
+	}
+
 	resp, err := svc.DeleteStack(&appstream.DeleteStackInput{
 		Name: aws.String(d.Id()),
 	})
+	if isAWSErr(err, appstream.ErrCodeResourceNotFoundException, "") {
+		return nil
+	}
 	if err != nil {
 		log.Printf("[ERROR] Error deleting Appstream Stack: %s", err)
 		return err
@@ -334,6 +577,139 @@ func resourceAppstreamStackDelete(d *schema.ResourceData, meta interface{}) erro
 
 }
 
+// listAssociatedFleets returns the names of every fleet associated with the stack.
+func listAssociatedFleets(svc *appstream.AppStream, stackName string) ([]string, error) {
+	fleets := make([]string, 0)
+	input := &appstream.ListAssociatedFleetsInput{
+		StackName: aws.String(stackName),
+	}
+	for {
+		resp, err := svc.ListAssociatedFleets(input)
+		if err != nil {
+			log.Printf("[ERROR] Error listing fleets associated with Appstream Stack: %s", err)
+			return nil, err
+		}
+		fleets = append(fleets, aws.StringValueSlice(resp.Names)...)
+		if aws.StringValue(resp.NextToken) == "" {
+			return fleets, nil
+		}
+		input.NextToken = resp.NextToken
+	}
+}
+
+// describeUserStackAssociations returns every user associated with the stack.
+func describeUserStackAssociations(svc *appstream.AppStream, stackName string) ([]*appstream.UserStackAssociation, error) {
+	users := make([]*appstream.UserStackAssociation, 0)
+	input := &appstream.DescribeUserStackAssociationsInput{
+		StackName: aws.String(stackName),
+	}
+	for {
+		resp, err := svc.DescribeUserStackAssociations(input)
+		if err != nil {
+			log.Printf("[ERROR] Error describing users associated with Appstream Stack: %s", err)
+			return nil, err
+		}
+		users = append(users, resp.UserStackAssociations...)
+		if aws.StringValue(resp.NextToken) == "" {
+			return users, nil
+		}
+		input.NextToken = resp.NextToken
+	}
+}
+
+// describeStack returns the named stack, or nil when it does not exist.
+func describeStack(svc *appstream.AppStream, name string) (*appstream.Stack, error) {
+	resp, err := svc.DescribeStacks(&appstream.DescribeStacksInput{
+		Names: aws.StringSlice([]string{name}),
+	})
+	if isAWSErr(err, appstream.ErrCodeResourceNotFoundException, "") {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("[ERROR] Error describing Appstream Stack: %s", err)
+		return nil, err
+	}
+	if len(resp.Stacks) == 0 {
+		return nil, nil
+	}
+	return resp.Stacks[0], nil
+}
+
+// resourceAppstreamStackCustomizeDiffSettingsGroup refuses to plan a change of settings_group
+// on a stack with persistent application settings unless allow_settings_group_change is set:
+// users start again from empty settings, the ones saved under the previous group stay in S3
+// but are no longer used.
+func resourceAppstreamStackCustomizeDiffSettingsGroup(diff *schema.ResourceDiff, meta interface{}) error {
+	if diff.Id() == "" || !diff.HasChange("application_settings.0.settings_group") {
+		return nil
+	}
+
+	o, n := diff.GetChange("application_settings.0.settings_group")
+	if o.(string) == "" || !diff.NewValueKnown("application_settings.0.settings_group") {
+		return nil
+	}
+	if diff.Get("allow_settings_group_change").(bool) {
+		log.Printf("[WARN] Changing application_settings settings_group of Appstream Stack (%s) from %q to %q orphans the application settings users saved under %q",
+			diff.Id(), o, n, o)
+		return nil
+	}
+	return fmt.Errorf("changing application_settings settings_group from %q to %q orphans the application settings users saved under %q, set allow_settings_group_change to true to confirm",
+		o, n, o)
+}
+
+func expandApplicationSettings(applicationSettings []interface{}) *appstream.ApplicationSettings {
+	if len(applicationSettings) == 0 || applicationSettings[0] == nil {
+		return nil
+	}
+
+	attr := applicationSettings[0].(map[string]interface{})
+	settings := &appstream.ApplicationSettings{
+		Enabled: aws.Bool(attr["enabled"].(bool)),
+	}
+	if v := attr["settings_group"].(string); v != "" {
+		settings.SettingsGroup = aws.String(v)
+	}
+	return settings
+}
+
+func flattenApplicationSettings(applicationSettings *appstream.ApplicationSettingsResponse) []interface{} {
+	if applicationSettings == nil {
+		return nil
+	}
+
+	return []interface{}{map[string]interface{}{
+		"enabled":        aws.BoolValue(applicationSettings.Enabled),
+		"s3_bucket_name": aws.StringValue(applicationSettings.S3BucketName),
+		"settings_group": aws.StringValue(applicationSettings.SettingsGroup),
+	}}
+}
+
+// resourceAppstreamStackCustomizeDiffStorageConnectors rejects duplicate connector types and
+// ONE_DRIVE or GOOGLE_DRIVE connectors without the domains users sign in with.
+func resourceAppstreamStackCustomizeDiffStorageConnectors(diff *schema.ResourceDiff, meta interface{}) error {
+	seen := make(map[string]bool)
+	for _, raw := range diff.Get("storage_connectors").(*schema.Set).List() {
+		attr := raw.(map[string]interface{})
+		connectorType := attr["connector_type"].(string)
+		if connectorType == "" {
+			continue
+		}
+
+		if seen[connectorType] {
+			return fmt.Errorf("storage_connectors: only one %s connector can be configured", connectorType)
+		}
+		seen[connectorType] = true
+
+		switch connectorType {
+		case appstream.StorageConnectorTypeOneDrive, appstream.StorageConnectorTypeGoogleDrive:
+			if attr["domains"].(*schema.Set).Len() == 0 {
+				return fmt.Errorf("storage_connectors: domains is required for %s connectors", connectorType)
+			}
+		}
+	}
+	return nil
+}
+
 func expandStorageConnectorConfigs(storageConnectorConfigs []interface{}) []*appstream.StorageConnector {
 	storageConnectorConfig := []*appstream.StorageConnector{}
 
@@ -343,11 +719,147 @@ func expandStorageConnectorConfigs(storageConnectorConfigs []interface{}) []*app
 		config := &appstream.StorageConnector{
 			ConnectorType: aws.String(configConnectorType),
 		}
+		if v, ok := configAttributes["domains"].(*schema.Set); ok && v.Len() > 0 {
+			config.Domains = expandStringSet(v)
+		}
+		if v, ok := configAttributes["resource_identifier"].(string); ok && v != "" {
+			config.ResourceIdentifier = aws.String(v)
+		}
 		storageConnectorConfig = append(storageConnectorConfig, config)
 	}
 	return storageConnectorConfig
 }
 
+// flattenStorageConnectors flattens every connector of the stack. The API fills in
+// resource_identifier for some connectors, it is only kept where the configuration sets it.
+func flattenStorageConnectors(storageConnectors []*appstream.StorageConnector, configured []interface{}) []interface{} {
+	withIdentifier := make(map[string]bool)
+	for _, raw := range configured {
+		attr := raw.(map[string]interface{})
+		if attr["resource_identifier"].(string) != "" {
+			withIdentifier[attr["connector_type"].(string)] = true
+		}
+	}
+
+	result := make([]interface{}, 0, len(storageConnectors))
+	for _, v := range storageConnectors {
+		connectorType := aws.StringValue(v.ConnectorType)
+		attr := map[string]interface{}{
+			"connector_type":      connectorType,
+			"domains":             flattenStringList(v.Domains),
+			"resource_identifier": "",
+		}
+		if withIdentifier[connectorType] {
+			attr["resource_identifier"] = aws.StringValue(v.ResourceIdentifier)
+		}
+		result = append(result, attr)
+	}
+	return result
+}
+
+// removedStorageConnectorTypes returns the connector types present in old but not in new.
+func removedStorageConnectorTypes(old, new []interface{}) []string {
+	kept := make(map[string]bool)
+	for _, raw := range new {
+		kept[raw.(map[string]interface{})["connector_type"].(string)] = true
+	}
+
+	removed := make([]string, 0)
+	for _, raw := range old {
+		connectorType := raw.(map[string]interface{})["connector_type"].(string)
+		if !kept[connectorType] {
+			removed = append(removed, connectorType)
+		}
+	}
+	return removed
+}
+
+// storageConnectorStackAttribute returns the UpdateStack attribute that deletes the storage
+// connector of the given type.
+func storageConnectorStackAttribute(connectorType string) (string, error) {
+	switch connectorType {
+	case appstream.StorageConnectorTypeHomefolders:
+		return appstream.StackAttributeStorageConnectorHomefolders, nil
+	case appstream.StorageConnectorTypeGoogleDrive:
+		return appstream.StackAttributeStorageConnectorGoogleDrive, nil
+	case appstream.StorageConnectorTypeOneDrive:
+		return appstream.StackAttributeStorageConnectorOneDrive, nil
+	}
+	return "", fmt.Errorf("storage connector type %q cannot be removed on its own", connectorType)
+}
+
+func expandStreamingExperienceSettings(streamingExperienceSettings []interface{}) *appstream.StreamingExperienceSettings {
+	if len(streamingExperienceSettings) == 0 || streamingExperienceSettings[0] == nil {
+		return nil
+	}
+
+	attr := streamingExperienceSettings[0].(map[string]interface{})
+	return &appstream.StreamingExperienceSettings{
+		PreferredProtocol: aws.String(attr["preferred_protocol"].(string)),
+	}
+}
+
+func flattenStreamingExperienceSettings(streamingExperienceSettings *appstream.StreamingExperienceSettings) []interface{} {
+	if streamingExperienceSettings == nil || aws.StringValue(streamingExperienceSettings.PreferredProtocol) == "" {
+		return nil
+	}
+
+	return []interface{}{map[string]interface{}{
+		"preferred_protocol": aws.StringValue(streamingExperienceSettings.PreferredProtocol),
+	}}
+}
+
+// appstreamUserSettingDefaults are the permissions the API gives a stack for actions it was
+// not configured with. Read leaves settings at their default out of state unless configured.
+var appstreamUserSettingDefaults = map[string]string{
+	appstream.ActionClipboardCopyFromLocalDevice: appstream.PermissionEnabled,
+	appstream.ActionClipboardCopyToLocalDevice:   appstream.PermissionEnabled,
+	appstream.ActionDomainPasswordSignin:         appstream.PermissionEnabled,
+	appstream.ActionDomainSmartCardSignin:        appstream.PermissionDisabled,
+	appstream.ActionFileDownload:                 appstream.PermissionEnabled,
+	appstream.ActionFileUpload:                   appstream.PermissionEnabled,
+	appstream.ActionPrintingToLocalDevice:        appstream.PermissionEnabled,
+}
+
+// clipboardDefaultMaximumLength is the limit the API reports for an enabled clipboard action
+// without maximum_length, and the largest one it accepts.
+const clipboardDefaultMaximumLength = 20971520
+
+func defaultUserSettingPermission(action string) string {
+	if v, ok := appstreamUserSettingDefaults[action]; ok {
+		return v
+	}
+	return appstream.PermissionEnabled
+}
+
+func isClipboardAction(action string) bool {
+	return action == appstream.ActionClipboardCopyFromLocalDevice || action == appstream.ActionClipboardCopyToLocalDevice
+}
+
+// resourceAppstreamStackCustomizeDiffUserSettings rejects duplicate actions and
+// maximum_length on actions other than the clipboard ones.
+func resourceAppstreamStackCustomizeDiffUserSettings(diff *schema.ResourceDiff, meta interface{}) error {
+	seen := make(map[string]bool)
+	for _, raw := range diff.Get("user_settings").(*schema.Set).List() {
+		attr := raw.(map[string]interface{})
+		action := attr["action"].(string)
+		if action == "" {
+			continue
+		}
+
+		if seen[action] {
+			return fmt.Errorf("user_settings: %s is configured more than once", action)
+		}
+		seen[action] = true
+
+		if attr["maximum_length"].(int) > 0 && !isClipboardAction(action) {
+			return fmt.Errorf("user_settings: maximum_length only applies to %s and %s, not %s",
+				appstream.ActionClipboardCopyFromLocalDevice, appstream.ActionClipboardCopyToLocalDevice, action)
+		}
+	}
+	return nil
+}
+
 func expandUserSettingConfigs(userSettingConfigs []interface{}) []*appstream.UserSetting {
 	userSettingList := []*appstream.UserSetting{}
 
@@ -363,7 +875,60 @@ func expandUserSettingConfigs(userSettingConfigs []interface{}) []*appstream.Use
 			Action:     aws.String(action),
 			Permission: aws.String(permission),
 		}
+		if v, ok := configAttributes["maximum_length"].(int); ok && v > 0 {
+			config.MaximumLength = aws.Int64(int64(v))
+		}
 		userSettingList = append(userSettingList, config)
 	}
 	return userSettingList
 }
+
+// flattenUserSettings returns the configured settings and any setting that differs from the
+// API default, so defaults the configuration never mentions do not show up as drift.
+func flattenUserSettings(userSettings []*appstream.UserSetting, configured []interface{}) []interface{} {
+	inConfig := make(map[string]bool)
+	configuredLength := make(map[string]int)
+	for _, raw := range configured {
+		attr := raw.(map[string]interface{})
+		inConfig[attr["action"].(string)] = true
+		configuredLength[attr["action"].(string)] = attr["maximum_length"].(int)
+	}
+
+	result := make([]interface{}, 0, len(userSettings))
+	for _, us := range userSettings {
+		action := aws.StringValue(us.Action)
+		permission := aws.StringValue(us.Permission)
+		maximumLength := int(aws.Int64Value(us.MaximumLength))
+		// The default limit is recorded as unset unless the configuration asks for it.
+		if maximumLength == clipboardDefaultMaximumLength && configuredLength[action] != clipboardDefaultMaximumLength {
+			maximumLength = 0
+		}
+
+		if !inConfig[action] && permission == defaultUserSettingPermission(action) && maximumLength == 0 {
+			continue
+		}
+		result = append(result, map[string]interface{}{
+			"action":         action,
+			"enabled":        permission == appstream.PermissionEnabled,
+			"maximum_length": maximumLength,
+		})
+	}
+	return result
+}
+
+// removedUserSettingActions returns the actions configured in old but not in new.
+func removedUserSettingActions(old, new []interface{}) []string {
+	kept := make(map[string]bool)
+	for _, raw := range new {
+		kept[raw.(map[string]interface{})["action"].(string)] = true
+	}
+
+	removed := make([]string, 0)
+	for _, raw := range old {
+		action := raw.(map[string]interface{})["action"].(string)
+		if !kept[action] {
+			removed = append(removed, action)
+		}
+	}
+	return removed
+}
@@ -0,0 +1,416 @@
+package appstream
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/appstream"
+	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+)
+
+func TestResourceAppstreamStack_replacement(t *testing.T) {
+	testCheckSchemaReplacement(t, resourceAppstreamStack(),
+		[]string{
+			"name",
+		},
+		[]string{
+			"access_endpoints",
+			"allow_settings_group_change",
+			"application_settings",
+			"deletion_protection",
+			"description",
+			"display_name",
+			"embed_host_domains",
+			"feedback_url",
+			"force_destroy",
+			"redirect_url",
+			"storage_connectors",
+			"streaming_experience_settings",
+			"tags",
+			"user_settings",
+		},
+	)
+}
+
+func TestResourceAppstreamStack_storageConnectorDomains(t *testing.T) {
+	cases := map[string]struct {
+		connectors []interface{}
+		expectErr  bool
+	}{
+		"home folders": {
+			[]interface{}{map[string]interface{}{"connector_type": "HOMEFOLDERS"}},
+			false,
+		},
+		"one drive with domains": {
+			[]interface{}{map[string]interface{}{"connector_type": "ONE_DRIVE", "domains": []interface{}{"example.com"}}},
+			false,
+		},
+		"one drive without domains": {
+			[]interface{}{map[string]interface{}{"connector_type": "ONE_DRIVE"}},
+			true,
+		},
+		"google drive without domains": {
+			[]interface{}{
+				map[string]interface{}{"connector_type": "HOMEFOLDERS"},
+				map[string]interface{}{"connector_type": "GOOGLE_DRIVE"},
+			},
+			true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := resourceAppstreamStack().Diff(&terraform.InstanceState{}, terraform.NewResourceConfigRaw(map[string]interface{}{
+				"name":               "test-stack",
+				"storage_connectors": tc.connectors,
+			}), nil)
+			if tc.expectErr && err == nil {
+				t.Error("expected an error")
+			}
+			if !tc.expectErr && err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+		})
+	}
+}
+
+func TestResourceAppstreamStack_settingsGroupChange(t *testing.T) {
+	state := map[string]string{
+		"name":                                  "test-stack",
+		"allow_settings_group_change":           "false",
+		"application_settings.#":                "1",
+		"application_settings.0.enabled":        "true",
+		"application_settings.0.s3_bucket_name": "appstream-app-settings-eu-west-1-123456789012-abcdefgh",
+		"application_settings.0.settings_group": "desktop",
+		"deletion_protection":                   "false",
+		"force_destroy":                         "false",
+	}
+	config := func(group string, allow bool) map[string]interface{} {
+		return map[string]interface{}{
+			"name":                        "test-stack",
+			"allow_settings_group_change": allow,
+			"application_settings": []interface{}{map[string]interface{}{
+				"enabled":        true,
+				"settings_group": group,
+			}},
+		}
+	}
+	plan := func(group string, allow bool) (*terraform.InstanceDiff, error) {
+		return resourceAppstreamStack().Diff(&terraform.InstanceState{
+			ID:         "test-stack",
+			Attributes: state,
+		}, terraform.NewResourceConfigRaw(config(group, allow)), nil)
+	}
+
+	if _, err := plan("kiosk", false); err == nil || !strings.Contains(err.Error(), "allow_settings_group_change") {
+		t.Fatalf("expected the settings_group change to be refused, got %v", err)
+	}
+
+	diff, err := plan("kiosk", true)
+	if err != nil {
+		t.Fatalf("error planning: %s", err)
+	}
+	attr := diff.Attributes["application_settings.0.settings_group"]
+	if attr == nil || attr.Old != "desktop" || attr.New != "kiosk" || attr.RequiresNew {
+		t.Errorf("expected an in-place settings_group change from desktop to kiosk, got %v", attr)
+	}
+
+	diff, err = plan("desktop", false)
+	if err != nil {
+		t.Fatalf("error planning: %s", err)
+	}
+	if !diff.Empty() {
+		t.Errorf("expected no diff for an unchanged settings_group, got %v", diff)
+	}
+}
+
+func TestResourceAppstreamStack_import(t *testing.T) {
+	server := newTestAppstreamServer(t)
+	server.pageSize = 1
+	for _, name := range []string{"a-stack", "test-stack"} {
+		server.addStack(&appstream.Stack{
+			Name:        aws.String(name),
+			Description: aws.String("Stack " + name),
+		})
+	}
+	server.tags["arn:aws:appstream:eu-west-1:123456789012:stack/test-stack"][deletionProtectionTagKey] = aws.String("true")
+	client := server.client(t)
+
+	state := testImportState(t, resourceAppstreamStack(), "test-stack", client)
+	for k, v := range map[string]string{
+		"name":                "test-stack",
+		"description":         "Stack test-stack",
+		"deletion_protection": "true",
+	} {
+		if state.Attributes[k] != v {
+			t.Errorf("expected %s %q, got %q", k, v, state.Attributes[k])
+		}
+	}
+}
+
+func TestFlattenUserSettings(t *testing.T) {
+	api := []*appstream.UserSetting{
+		{Action: aws.String(appstream.ActionClipboardCopyFromLocalDevice), Permission: aws.String(appstream.PermissionEnabled), MaximumLength: aws.Int64(1024)},
+		{Action: aws.String(appstream.ActionClipboardCopyToLocalDevice), Permission: aws.String(appstream.PermissionEnabled), MaximumLength: aws.Int64(clipboardDefaultMaximumLength)},
+		{Action: aws.String(appstream.ActionFileDownload), Permission: aws.String(appstream.PermissionDisabled)},
+		{Action: aws.String(appstream.ActionFileUpload), Permission: aws.String(appstream.PermissionEnabled)},
+		{Action: aws.String(appstream.ActionDomainSmartCardSignin), Permission: aws.String(appstream.PermissionDisabled)},
+	}
+	configured := []interface{}{
+		map[string]interface{}{"action": appstream.ActionFileUpload, "enabled": true, "maximum_length": 0},
+	}
+
+	got := make(map[string]map[string]interface{})
+	for _, raw := range flattenUserSettings(api, configured) {
+		attr := raw.(map[string]interface{})
+		got[attr["action"].(string)] = attr
+	}
+
+	for _, action := range []string{appstream.ActionClipboardCopyFromLocalDevice, appstream.ActionFileDownload, appstream.ActionFileUpload} {
+		if _, ok := got[action]; !ok {
+			t.Errorf("expected %s to be kept", action)
+		}
+	}
+	for _, action := range []string{appstream.ActionClipboardCopyToLocalDevice, appstream.ActionDomainSmartCardSignin} {
+		if _, ok := got[action]; ok {
+			t.Errorf("expected default %s to be left out", action)
+		}
+	}
+	if v := got[appstream.ActionClipboardCopyFromLocalDevice]["maximum_length"]; v != 1024 {
+		t.Errorf("expected maximum_length 1024, got %v", v)
+	}
+}
+
+// testAppstreamStackServer returns a stand-in server holding one stack and the state Read
+// produces for it.
+func testAppstreamStackServer(t *testing.T) (*testAppstreamServer, *AWSClient, *terraform.InstanceState) {
+	t.Helper()
+
+	server := newTestAppstreamServer(t)
+	server.addStack(&appstream.Stack{
+		Name:             aws.String("test-stack"),
+		Description:      aws.String("before"),
+		EmbedHostDomains: aws.StringSlice([]string{"portal.example.com"}),
+		FeedbackURL:      aws.String("https://example.com/feedback"),
+		UserSettings: []*appstream.UserSetting{
+			{Action: aws.String(appstream.ActionFileDownload), Permission: aws.String(appstream.PermissionDisabled)},
+			{Action: aws.String(appstream.ActionFileUpload), Permission: aws.String(appstream.PermissionEnabled)},
+		},
+	})
+	client := server.client(t)
+
+	state, err := resourceAppstreamStack().RefreshWithoutUpgrade(&terraform.InstanceState{
+		ID:         "test-stack",
+		Attributes: map[string]string{"name": "test-stack"},
+	}, client)
+	if err != nil {
+		t.Fatalf("error reading stack: %s", err)
+	}
+	return server, client, state
+}
+
+// testAppstreamStackApply plans and applies config against state, returning the new state.
+func testAppstreamStackApply(t *testing.T, client *AWSClient, state *terraform.InstanceState, config map[string]interface{}) (*terraform.InstanceState, error) {
+	t.Helper()
+
+	r := resourceAppstreamStack()
+	diff, err := r.Diff(state, terraform.NewResourceConfigRaw(config), client)
+	if err != nil {
+		t.Fatalf("error planning: %s", err)
+	}
+	if diff == nil {
+		t.Fatal("expected a diff")
+	}
+	return r.Apply(state, diff, client)
+}
+
+func TestResourceAppstreamStackUpdate(t *testing.T) {
+	server, client, state := testAppstreamStackServer(t)
+
+	newState, err := testAppstreamStackApply(t, client, state, map[string]interface{}{
+		"name":        "test-stack",
+		"description": "after",
+		"user_settings": []interface{}{
+			map[string]interface{}{"action": appstream.ActionClipboardCopyToLocalDevice, "enabled": true, "maximum_length": 2048},
+		},
+		"tags": map[string]interface{}{"team": "euc"},
+	})
+	if err != nil {
+		t.Fatalf("error applying: %s", err)
+	}
+
+	if n := server.calls["UpdateStack"]; n != 1 {
+		t.Errorf("expected one UpdateStack call, got %d", n)
+	}
+
+	stack := server.stacks["test-stack"]
+	if got := aws.StringValue(stack.Description); got != "after" {
+		t.Errorf("expected description to be updated, got %q", got)
+	}
+	if len(stack.EmbedHostDomains) != 0 || stack.FeedbackURL != nil {
+		t.Errorf("expected embed_host_domains and feedback_url to be deleted, got %v and %v", stack.EmbedHostDomains, stack.FeedbackURL)
+	}
+	for _, us := range stack.UserSettings {
+		if aws.StringValue(us.Action) == appstream.ActionFileDownload && aws.StringValue(us.Permission) != appstream.PermissionEnabled {
+			t.Error("expected removed FILE_DOWNLOAD setting to be reset to its default")
+		}
+	}
+
+	// The state saved after the update must match what a fresh read of the API returns.
+	refreshed, err := resourceAppstreamStack().RefreshWithoutUpgrade(newState, client)
+	if err != nil {
+		t.Fatalf("error reading stack: %s", err)
+	}
+	for k, v := range refreshed.Attributes {
+		if newState.Attributes[k] != v {
+			t.Errorf("%s: state has %q after update, API has %q", k, newState.Attributes[k], v)
+		}
+	}
+	for k, v := range map[string]string{
+		"description":          "after",
+		"embed_host_domains.#": "0",
+		"feedback_url":         "",
+		"tags.%":               "1",
+		"tags.team":            "euc",
+		"user_settings.#":      "1",
+	} {
+		if newState.Attributes[k] != v {
+			t.Errorf("%s: expected %q, got %q", k, v, newState.Attributes[k])
+		}
+	}
+}
+
+func TestResourceAppstreamStackUpdate_removeStorageConnector(t *testing.T) {
+	server, client, _ := testAppstreamStackServer(t)
+	server.stacks["test-stack"].StorageConnectors = []*appstream.StorageConnector{
+		{ConnectorType: aws.String(appstream.StorageConnectorTypeHomefolders)},
+		{ConnectorType: aws.String(appstream.StorageConnectorTypeOneDrive), Domains: aws.StringSlice([]string{"example.com"})},
+	}
+	state, err := resourceAppstreamStack().RefreshWithoutUpgrade(&terraform.InstanceState{
+		ID:         "test-stack",
+		Attributes: map[string]string{"name": "test-stack"},
+	}, client)
+	if err != nil {
+		t.Fatalf("error reading stack: %s", err)
+	}
+
+	_, err = testAppstreamStackApply(t, client, state, map[string]interface{}{
+		"name": "test-stack",
+		"storage_connectors": []interface{}{
+			map[string]interface{}{"connector_type": appstream.StorageConnectorTypeHomefolders},
+		},
+	})
+	if err != nil {
+		t.Fatalf("error updating: %s", err)
+	}
+
+	connectors := server.stacks["test-stack"].StorageConnectors
+	if len(connectors) != 1 || aws.StringValue(connectors[0].ConnectorType) != appstream.StorageConnectorTypeHomefolders {
+		t.Errorf("expected only the HOMEFOLDERS connector to be left, got %v", connectors)
+	}
+}
+
+func TestResourceAppstreamStackUpdate_clipboardDefaultLength(t *testing.T) {
+	server, client, state := testAppstreamStackServer(t)
+
+	config := map[string]interface{}{
+		"name":        "test-stack",
+		"description": "before",
+		"user_settings": []interface{}{
+			map[string]interface{}{"action": appstream.ActionClipboardCopyFromLocalDevice, "enabled": true},
+			map[string]interface{}{"action": appstream.ActionFileDownload, "enabled": false},
+		},
+	}
+	state, err := testAppstreamStackApply(t, client, state, config)
+	if err != nil {
+		t.Fatalf("error applying: %s", err)
+	}
+	for _, us := range server.stacks["test-stack"].UserSettings {
+		if aws.StringValue(us.Action) == appstream.ActionClipboardCopyFromLocalDevice && aws.Int64Value(us.MaximumLength) != clipboardDefaultMaximumLength {
+			t.Fatalf("expected the API to report the default maximum length, got %d", aws.Int64Value(us.MaximumLength))
+		}
+	}
+
+	state, err = resourceAppstreamStack().RefreshWithoutUpgrade(state, client)
+	if err != nil {
+		t.Fatalf("error refreshing: %s", err)
+	}
+	diff, err := resourceAppstreamStack().Diff(state, terraform.NewResourceConfigRaw(config), client)
+	if err != nil {
+		t.Fatalf("error planning: %s", err)
+	}
+	if !diff.Empty() {
+		t.Errorf("expected no drift from the default maximum length, got %v", diff.Attributes)
+	}
+}
+
+func TestStorageConnectorStackAttribute(t *testing.T) {
+	for connectorType, expected := range map[string]string{
+		appstream.StorageConnectorTypeHomefolders: appstream.StackAttributeStorageConnectorHomefolders,
+		appstream.StorageConnectorTypeGoogleDrive: appstream.StackAttributeStorageConnectorGoogleDrive,
+		appstream.StorageConnectorTypeOneDrive:    appstream.StackAttributeStorageConnectorOneDrive,
+	} {
+		got, err := storageConnectorStackAttribute(connectorType)
+		if err != nil || got != expected {
+			t.Errorf("%s: expected %s, got %q (%v)", connectorType, expected, got, err)
+		}
+	}
+
+	if _, err := storageConnectorStackAttribute("DROPBOX"); err == nil {
+		t.Error("expected an error for an unknown connector type")
+	}
+}
+
+func TestResourceAppstreamStackUpdate_tagsOnly(t *testing.T) {
+	server, client, state := testAppstreamStackServer(t)
+
+	config := map[string]interface{}{
+		"name":               "test-stack",
+		"description":        "before",
+		"embed_host_domains": []interface{}{"portal.example.com"},
+		"feedback_url":       "https://example.com/feedback",
+		"user_settings": []interface{}{
+			map[string]interface{}{"action": appstream.ActionFileDownload, "enabled": false},
+		},
+		"tags": map[string]interface{}{"team": "euc"},
+	}
+	newState, err := testAppstreamStackApply(t, client, state, config)
+	if err != nil {
+		t.Fatalf("error applying: %s", err)
+	}
+
+	if n := server.calls["UpdateStack"]; n != 0 {
+		t.Errorf("expected no UpdateStack call for a tag change, got %d", n)
+	}
+	if got := aws.StringValue(server.tags[aws.StringValue(server.stacks["test-stack"].Arn)]["team"]); got != "euc" {
+		t.Errorf("expected the team tag to be applied, got %q", got)
+	}
+	if got := newState.Attributes["tags.team"]; got != "euc" {
+		t.Errorf("expected the team tag in state, got %q", got)
+	}
+}
+
+func TestResourceAppstreamStackUpdate_error(t *testing.T) {
+	server, client, state := testAppstreamStackServer(t)
+	server.fail["UpdateStack"] = true
+
+	newState, err := testAppstreamStackApply(t, client, state, map[string]interface{}{
+		"name":        "test-stack",
+		"description": "after",
+		"tags":        map[string]interface{}{"team": "euc"},
+	})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	if got := newState.Attributes["description"]; got != "before" {
+		t.Errorf("expected description to stay %q after a failed update, got %q", "before", got)
+	}
+	if got := newState.Attributes["tags.team"]; got != "" {
+		t.Errorf("expected tags not to be saved after a failed update, got %q", got)
+	}
+	if n := server.calls["TagResource"]; n != 0 {
+		t.Errorf("expected no TagResource call after a failed update, got %d", n)
+	}
+}
@@ -0,0 +1,554 @@
+package appstream
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/appstream"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	homedir "github.com/mitchellh/go-homedir"
+)
+
+// appstreamThemeImage describes one of the two images of a stack theme. The API only
+// takes S3 locations, so the provider uploads the image to asset_bucket first and keys
+// the object by a hash of its content.
+type appstreamThemeImage struct {
+	name         string
+	objectName   string
+	contentTypes map[string]string
+}
+
+var (
+	appstreamThemeOrganizationLogo = appstreamThemeImage{
+		name:       "organization_logo",
+		objectName: "organization-logo",
+		contentTypes: map[string]string{
+			"image/jpeg": ".jpg",
+			"image/png":  ".png",
+		},
+	}
+	appstreamThemeFavicon = appstreamThemeImage{
+		name:       "favicon",
+		objectName: "favicon",
+		contentTypes: map[string]string{
+			"image/jpeg":   ".jpg",
+			"image/png":    ".png",
+			"image/x-icon": ".ico",
+		},
+	}
+
+	appstreamThemeImages = []appstreamThemeImage{appstreamThemeOrganizationLogo, appstreamThemeFavicon}
+)
+
+func (i appstreamThemeImage) key(suffix string) string {
+	return i.name + "_" + suffix
+}
+
+// url returns the URL the theme serves the image from.
+func (i appstreamThemeImage) url(theme *appstreamTheme) *string {
+	if i.name == appstreamThemeOrganizationLogo.name {
+		return theme.ThemeOrganizationLogoURL
+	}
+	return theme.ThemeFaviconURL
+}
+
+func resourceAppstreamStackTheme() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceAppstreamStackThemeCreate,
+		Read:   resourceAppstreamStackThemeRead,
+		Update: resourceAppstreamStackThemeUpdate,
+		Delete: resourceAppstreamStackThemeDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		CustomizeDiff: resourceAppstreamStackThemeCustomizeDiffImages,
+
+		Schema: map[string]*schema.Schema{
+			"asset_bucket": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.StringLenBetween(3, 63),
+			},
+
+			"asset_key_prefix": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "appstream-themes/",
+			},
+
+			"favicon_base64": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: []string{"favicon_base64", "favicon_path"},
+			},
+
+			"favicon_path": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			"favicon_s3_key": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"favicon_sha256": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"favicon_url": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"footer_links": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"display_name": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validation.StringLenBetween(1, 300),
+						},
+						"url": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateAppstreamURL,
+						},
+					},
+				},
+			},
+
+			"organization_logo_base64": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: []string{"organization_logo_base64", "organization_logo_path"},
+			},
+
+			"organization_logo_path": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			"organization_logo_s3_key": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"organization_logo_sha256": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"organization_logo_url": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"stack_name": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateAppstreamName,
+			},
+
+			"state": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      themeStateEnabled,
+				ValidateFunc: validation.StringInSlice(themeState_Values(), false),
+			},
+
+			"theme_styling": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.StringInSlice(themeStyling_Values(), false),
+			},
+
+			"title_text": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.StringLenBetween(1, 300),
+			},
+		},
+	}
+}
+
+func resourceAppstreamStackThemeCreate(d *schema.ResourceData, meta interface{}) error {
+	svc := meta.(*AWSClient).appstreamconn
+	s3conn := meta.(*AWSClient).s3conn
+
+	stackName := d.Get("stack_name").(string)
+	CreateThemeForStackInputOpts := &createThemeForStackInput{
+		FooterLinks:  expandThemeFooterLinks(d.Get("footer_links").([]interface{})),
+		StackName:    aws.String(stackName),
+		ThemeStyling: aws.String(d.Get("theme_styling").(string)),
+		TitleText:    aws.String(d.Get("title_text").(string)),
+	}
+
+	logo, err := uploadAppstreamThemeImage(s3conn, d, appstreamThemeOrganizationLogo)
+	if err != nil {
+		return err
+	}
+	CreateThemeForStackInputOpts.OrganizationLogoS3Location = logo
+
+	favicon, err := uploadAppstreamThemeImage(s3conn, d, appstreamThemeFavicon)
+	if err != nil {
+		deleteAppstreamThemeImage(s3conn, logo)
+		return err
+	}
+	CreateThemeForStackInputOpts.FaviconS3Location = favicon
+
+	log.Printf("[DEBUG] Run configuration: %s", CreateThemeForStackInputOpts)
+	resp, err := createThemeForStack(svc, CreateThemeForStackInputOpts)
+	if err != nil {
+		log.Printf("[ERROR] Error creating Appstream Stack theme: %s", err)
+		deleteAppstreamThemeImage(s3conn, logo)
+		deleteAppstreamThemeImage(s3conn, favicon)
+		return err
+	}
+	log.Printf("[DEBUG] %s", resp)
+
+	d.SetId(stackName)
+
+	// New themes are always enabled, a disabled one takes a second call.
+	if v := d.Get("state").(string); v != themeStateEnabled {
+		if _, err := updateThemeForStack(svc, &updateThemeForStackInput{
+			StackName: aws.String(stackName),
+			State:     aws.String(v),
+		}); err != nil {
+			log.Printf("[ERROR] Error setting Appstream Stack theme state: %s", err)
+			return err
+		}
+	}
+
+	return resourceAppstreamStackThemeRead(d, meta)
+}
+
+func resourceAppstreamStackThemeRead(d *schema.ResourceData, meta interface{}) error {
+	svc := meta.(*AWSClient).appstreamconn
+
+	resp, err := describeThemeForStack(svc, &describeThemeForStackInput{
+		StackName: aws.String(d.Id()),
+	})
+	if isAWSErr(err, appstream.ErrCodeResourceNotFoundException, "") || (err == nil && resp.Theme == nil) {
+		log.Printf("[WARN] Appstream Stack theme (%s) not found, removing from state", d.Id())
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		log.Printf("[ERROR] Error describing Appstream Stack theme: %s", err)
+		return err
+	}
+
+	theme := resp.Theme
+	d.Set("stack_name", d.Id())
+	d.Set("state", theme.State)
+	d.Set("theme_styling", theme.ThemeStyling)
+	d.Set("title_text", theme.ThemeTitleText)
+
+	// The theme serves the images from URLs of its own. A URL other than the one recorded
+	// at apply time means the image was replaced out of band, so its hash is cleared and
+	// the next plan uploads the configured image again.
+	for _, image := range appstreamThemeImages {
+		url := image.url(theme)
+		if v := d.Get(image.key("url")).(string); v != "" && v != aws.StringValue(url) {
+			log.Printf("[WARN] Appstream Stack theme (%s) %s changed outside of Terraform", d.Id(), image.name)
+			d.Set(image.key("sha256"), "")
+		}
+		d.Set(image.key("url"), url)
+	}
+
+	if err := d.Set("footer_links", flattenThemeFooterLinks(theme.ThemeFooterLinks)); err != nil {
+		log.Printf("[ERROR] Error setting footer links: %s", err)
+		return err
+	}
+
+	return nil
+}
+
+func resourceAppstreamStackThemeUpdate(d *schema.ResourceData, meta interface{}) error {
+	svc := meta.(*AWSClient).appstreamconn
+	s3conn := meta.(*AWSClient).s3conn
+
+	// Nothing is written to state unless UpdateThemeForStack succeeds, the theme keeps
+	// pointing at the previous objects until then.
+	d.Partial(true)
+
+	UpdateThemeForStackInputOpts := &updateThemeForStackInput{
+		StackName: aws.String(d.Id()),
+	}
+
+	if d.HasChange("footer_links") {
+		if v := d.Get("footer_links").([]interface{}); len(v) > 0 {
+			UpdateThemeForStackInputOpts.FooterLinks = expandThemeFooterLinks(v)
+		} else {
+			UpdateThemeForStackInputOpts.AttributesToDelete = aws.StringSlice([]string{themeAttributeFooterLinks})
+		}
+	}
+
+	if d.HasChange("state") {
+		UpdateThemeForStackInputOpts.State = aws.String(d.Get("state").(string))
+	}
+
+	if d.HasChange("theme_styling") {
+		UpdateThemeForStackInputOpts.ThemeStyling = aws.String(d.Get("theme_styling").(string))
+	}
+
+	if d.HasChange("title_text") {
+		UpdateThemeForStackInputOpts.TitleText = aws.String(d.Get("title_text").(string))
+	}
+
+	// replaced holds the objects the theme stops using, uploaded the new ones, which are
+	// removed again if the update fails.
+	var replaced, uploaded []*appstreamS3Location
+	for _, image := range appstreamThemeImages {
+		if !d.HasChanges("asset_bucket", "asset_key_prefix", image.key("sha256")) {
+			continue
+		}
+
+		oldBucket, _ := d.GetChange("asset_bucket")
+		oldKey, _ := d.GetChange(image.key("s3_key"))
+		location, err := uploadAppstreamThemeImage(s3conn, d, image)
+		if err != nil {
+			for _, location := range uploaded {
+				deleteAppstreamThemeImage(s3conn, location)
+			}
+			return err
+		}
+		if image.name == appstreamThemeOrganizationLogo.name {
+			UpdateThemeForStackInputOpts.OrganizationLogoS3Location = location
+		} else {
+			UpdateThemeForStackInputOpts.FaviconS3Location = location
+		}
+
+		if oldBucket.(string) == aws.StringValue(location.S3Bucket) && oldKey.(string) == aws.StringValue(location.S3Key) {
+			continue
+		}
+		uploaded = append(uploaded, location)
+		if oldKey.(string) != "" {
+			replaced = append(replaced, &appstreamS3Location{
+				S3Bucket: aws.String(oldBucket.(string)),
+				S3Key:    aws.String(oldKey.(string)),
+			})
+		}
+	}
+
+	log.Printf("[DEBUG] Run configuration: %s", UpdateThemeForStackInputOpts)
+	resp, err := updateThemeForStack(svc, UpdateThemeForStackInputOpts)
+	if err != nil {
+		log.Printf("[ERROR] Error updating Appstream Stack theme: %s", err)
+		for _, location := range uploaded {
+			deleteAppstreamThemeImage(s3conn, location)
+		}
+		return err
+	}
+	log.Printf("[DEBUG] %s", resp)
+
+	d.Partial(false)
+
+	for _, location := range replaced {
+		deleteAppstreamThemeImage(s3conn, location)
+	}
+
+	return resourceAppstreamStackThemeRead(d, meta)
+}
+
+func resourceAppstreamStackThemeDelete(d *schema.ResourceData, meta interface{}) error {
+	svc := meta.(*AWSClient).appstreamconn
+	s3conn := meta.(*AWSClient).s3conn
+
+	resp, err := deleteThemeForStack(svc, &deleteThemeForStackInput{
+		StackName: aws.String(d.Id()),
+	})
+	if err != nil && !isAWSErr(err, appstream.ErrCodeResourceNotFoundException, "") {
+		log.Printf("[ERROR] Error deleting Appstream Stack theme: %s", err)
+		return err
+	}
+	log.Printf("[DEBUG] %s", resp)
+
+	for _, image := range appstreamThemeImages {
+		if v := d.Get(image.key("s3_key")).(string); v != "" {
+			deleteAppstreamThemeImage(s3conn, &appstreamS3Location{
+				S3Bucket: aws.String(d.Get("asset_bucket").(string)),
+				S3Key:    aws.String(v),
+			})
+		}
+	}
+
+	return nil
+}
+
+// resourceAppstreamStackThemeCustomizeDiffImages validates the images at plan time and
+// records their hashes, so a changed file or base64 value shows up as a diff even though
+// the path or value in the configuration stays the same.
+func resourceAppstreamStackThemeCustomizeDiffImages(diff *schema.ResourceDiff, meta interface{}) error {
+	for _, image := range appstreamThemeImages {
+		if !diff.NewValueKnown(image.key("path")) || !diff.NewValueKnown(image.key("base64")) {
+			for _, k := range []string{"sha256", "s3_key", "url"} {
+				if err := diff.SetNewComputed(image.key(k)); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		content, err := loadAppstreamThemeImage(diff.Get(image.key("path")).(string), diff.Get(image.key("base64")).(string))
+		if err != nil {
+			return fmt.Errorf("%s: %s", image.name, err)
+		}
+		if _, err := image.extension(content); err != nil {
+			return err
+		}
+
+		sum := sha256Hex(content)
+		if diff.Get(image.key("sha256")).(string) != sum {
+			if err := diff.SetNew(image.key("sha256"), sum); err != nil {
+				return err
+			}
+		} else if !diff.HasChange("asset_bucket") && !diff.HasChange("asset_key_prefix") {
+			continue
+		}
+		if diff.Id() != "" {
+			for _, k := range []string{"s3_key", "url"} {
+				if err := diff.SetNewComputed(image.key(k)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// loadAppstreamThemeImage returns the content of an image given either as a local path
+// or as base64.
+func loadAppstreamThemeImage(path, content string) ([]byte, error) {
+	if path != "" {
+		p, err := homedir.Expand(path)
+		if err != nil {
+			return nil, err
+		}
+		return ioutil.ReadFile(p)
+	}
+
+	b, err := base64.StdEncoding.DecodeString(content)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding base64 image: %s", err)
+	}
+	return b, nil
+}
+
+// extension checks the content sniffs as one of the image types the theme accepts and
+// returns the file extension used for the S3 object.
+func (i appstreamThemeImage) extension(content []byte) (string, error) {
+	if len(content) == 0 {
+		return "", fmt.Errorf("%s: image is empty", i.name)
+	}
+
+	contentType := http.DetectContentType(content)
+	if ext, ok := i.contentTypes[contentType]; ok {
+		return ext, nil
+	}
+
+	allowed := make([]string, 0, len(i.contentTypes))
+	for k := range i.contentTypes {
+		allowed = append(allowed, k)
+	}
+	sort.Strings(allowed)
+	return "", fmt.Errorf("%s: expected one of %s, got %s", i.name, strings.Join(allowed, ", "), contentType)
+}
+
+// uploadAppstreamThemeImage uploads an image to asset_bucket and records its hash and
+// object key.
+func uploadAppstreamThemeImage(conn *s3.S3, d *schema.ResourceData, image appstreamThemeImage) (*appstreamS3Location, error) {
+	content, err := loadAppstreamThemeImage(d.Get(image.key("path")).(string), d.Get(image.key("base64")).(string))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", image.name, err)
+	}
+	ext, err := image.extension(content)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256Hex(content)
+	location := &appstreamS3Location{
+		S3Bucket: aws.String(d.Get("asset_bucket").(string)),
+		S3Key:    aws.String(fmt.Sprintf("%s%s/%s-%s%s", d.Get("asset_key_prefix").(string), d.Get("stack_name").(string), image.objectName, sum[:16], ext)),
+	}
+
+	log.Printf("[DEBUG] Uploading Appstream Stack theme %s to s3://%s/%s", image.name, aws.StringValue(location.S3Bucket), aws.StringValue(location.S3Key))
+	_, err = conn.PutObject(&s3.PutObjectInput{
+		Body:        bytes.NewReader(content),
+		Bucket:      location.S3Bucket,
+		ContentType: aws.String(http.DetectContentType(content)),
+		Key:         location.S3Key,
+	})
+	if err != nil {
+		log.Printf("[ERROR] Error uploading Appstream Stack theme %s: %s", image.name, err)
+		return nil, err
+	}
+
+	d.Set(image.key("sha256"), sum)
+	d.Set(image.key("s3_key"), location.S3Key)
+
+	return location, nil
+}
+
+// deleteAppstreamThemeImage removes an object the theme does not use. A failure only
+// leaves the object behind, so it is logged rather than returned.
+func deleteAppstreamThemeImage(conn *s3.S3, location *appstreamS3Location) {
+	_, err := conn.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: location.S3Bucket,
+		Key:    location.S3Key,
+	})
+	if err != nil {
+		log.Printf("[WARN] Error deleting s3://%s/%s: %s", aws.StringValue(location.S3Bucket), aws.StringValue(location.S3Key), err)
+	}
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func expandThemeFooterLinks(footerLinks []interface{}) []*appstreamThemeFooterLink {
+	if len(footerLinks) == 0 {
+		return nil
+	}
+
+	links := make([]*appstreamThemeFooterLink, 0, len(footerLinks))
+	for _, v := range footerLinks {
+		link := v.(map[string]interface{})
+		links = append(links, &appstreamThemeFooterLink{
+			DisplayName:   aws.String(link["display_name"].(string)),
+			FooterLinkURL: aws.String(link["url"].(string)),
+		})
+	}
+	return links
+}
+
+func flattenThemeFooterLinks(footerLinks []*appstreamThemeFooterLink) []interface{} {
+	links := make([]interface{}, 0, len(footerLinks))
+	for _, v := range footerLinks {
+		links = append(links, map[string]interface{}{
+			"display_name": aws.StringValue(v.DisplayName),
+			"url":          aws.StringValue(v.FooterLinkURL),
+		})
+	}
+	return links
+}
@@ -0,0 +1,285 @@
+package appstream
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/appstream"
+	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+)
+
+// Smallest headers http.DetectContentType recognises for each type.
+var (
+	testThemePNG = []byte("\x89PNG\x0D\x0A\x1A\x0A")
+	testThemeICO = []byte("\x00\x00\x01\x00")
+)
+
+func TestResourceAppstreamStackTheme_replacement(t *testing.T) {
+	testCheckSchemaReplacement(t, resourceAppstreamStackTheme(),
+		[]string{
+			"stack_name",
+		},
+		[]string{
+			"asset_bucket",
+			"asset_key_prefix",
+			"favicon_base64",
+			"favicon_path",
+			"footer_links",
+			"organization_logo_base64",
+			"organization_logo_path",
+			"state",
+			"theme_styling",
+			"title_text",
+		},
+	)
+}
+
+func TestAppstreamThemeImageExtension(t *testing.T) {
+	cases := map[string]struct {
+		image   appstreamThemeImage
+		content []byte
+		ext     string
+		err     bool
+	}{
+		"logo png":    {appstreamThemeOrganizationLogo, testThemePNG, ".png", false},
+		"logo ico":    {appstreamThemeOrganizationLogo, testThemeICO, "", true},
+		"favicon ico": {appstreamThemeFavicon, testThemeICO, ".ico", false},
+		"text":        {appstreamThemeFavicon, []byte("not an image"), "", true},
+		"empty":       {appstreamThemeFavicon, nil, "", true},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ext, err := tc.image.extension(tc.content)
+			if (err != nil) != tc.err {
+				t.Fatalf("expected error %t, got %v", tc.err, err)
+			}
+			if ext != tc.ext {
+				t.Errorf("expected extension %q, got %q", tc.ext, ext)
+			}
+		})
+	}
+}
+
+func TestResourceAppstreamStackTheme_imageDiff(t *testing.T) {
+	logo := filepath.Join(t.TempDir(), "logo.png")
+	if err := ioutil.WriteFile(logo, append(testThemePNG, "v2"...), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	state := map[string]string{
+		"asset_bucket":             "themes",
+		"asset_key_prefix":         "appstream-themes/",
+		"favicon_base64":           base64.StdEncoding.EncodeToString(testThemeICO),
+		"favicon_s3_key":           "appstream-themes/test-stack/favicon-" + sha256Hex(testThemeICO)[:16] + ".ico",
+		"favicon_sha256":           sha256Hex(testThemeICO),
+		"organization_logo_path":   logo,
+		"organization_logo_s3_key": "appstream-themes/test-stack/organization-logo-" + sha256Hex(testThemePNG)[:16] + ".png",
+		"organization_logo_sha256": sha256Hex(testThemePNG),
+		"stack_name":               "test-stack",
+		"state":                    themeStateEnabled,
+		"theme_styling":            "BLUE",
+		"title_text":               "Test",
+	}
+	config := map[string]interface{}{
+		"asset_bucket":           "themes",
+		"favicon_base64":         base64.StdEncoding.EncodeToString(testThemeICO),
+		"organization_logo_path": logo,
+		"stack_name":             "test-stack",
+		"theme_styling":          "BLUE",
+		"title_text":             "Test",
+	}
+
+	diff, err := resourceAppstreamStackTheme().Diff(&terraform.InstanceState{
+		ID:         "test-stack",
+		Attributes: state,
+	}, terraform.NewResourceConfigRaw(config), nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if diff == nil {
+		t.Fatal("expected a diff for the changed logo")
+	}
+
+	if d := diff.Attributes["organization_logo_sha256"]; d == nil || d.New != sha256Hex(append(testThemePNG, "v2"...)) {
+		t.Errorf("expected organization_logo_sha256 to change, got %#v", d)
+	}
+	if d := diff.Attributes["organization_logo_s3_key"]; d == nil || !d.NewComputed {
+		t.Errorf("expected organization_logo_s3_key to be recomputed, got %#v", d)
+	}
+	for _, k := range []string{"favicon_sha256", "favicon_s3_key"} {
+		if d := diff.Attributes[k]; d != nil {
+			t.Errorf("expected no diff on %s, got %#v", k, d)
+		}
+	}
+	if diff.RequiresNew() {
+		t.Error("expected the logo to be updated in place")
+	}
+
+	config["organization_logo_path"] = filepath.Join(filepath.Dir(logo), "missing.png")
+	if _, err := resourceAppstreamStackTheme().Diff(&terraform.InstanceState{
+		ID:         "test-stack",
+		Attributes: state,
+	}, terraform.NewResourceConfigRaw(config), nil); err == nil {
+		t.Error("expected an error for a missing logo file")
+	}
+}
+
+func testAppstreamStackThemeConfig(logo []byte) map[string]interface{} {
+	return map[string]interface{}{
+		"asset_bucket":             "themes",
+		"favicon_base64":           base64.StdEncoding.EncodeToString(testThemeICO),
+		"organization_logo_base64": base64.StdEncoding.EncodeToString(logo),
+		"stack_name":               "test-stack",
+		"theme_styling":            "BLUE",
+		"title_text":               "Test",
+	}
+}
+
+func testApplyAppstreamStackTheme(t *testing.T, client *AWSClient, state *terraform.InstanceState, config map[string]interface{}) (*terraform.InstanceState, error) {
+	t.Helper()
+
+	r := resourceAppstreamStackTheme()
+	diff, err := r.Diff(state, terraform.NewResourceConfigRaw(config), client)
+	if err != nil {
+		t.Fatalf("error planning: %s", err)
+	}
+	if diff.Empty() {
+		t.Fatal("expected a diff")
+	}
+	return r.Apply(state, diff, client)
+}
+
+func TestResourceAppstreamStackTheme_lifecycle(t *testing.T) {
+	server := newTestAppstreamServer(t)
+	server.addStack(&appstream.Stack{Name: aws.String("test-stack")})
+	client := server.client(t)
+	r := resourceAppstreamStackTheme()
+
+	logoV1 := testThemePNG
+	logoV2 := append(append([]byte{}, testThemePNG...), "v2"...)
+	logoKey := func(logo []byte) string {
+		return "appstream-themes/test-stack/organization-logo-" + sha256Hex(logo)[:16] + ".png"
+	}
+	faviconKey := "appstream-themes/test-stack/favicon-" + sha256Hex(testThemeICO)[:16] + ".ico"
+
+	state, err := testApplyAppstreamStackTheme(t, client, nil, testAppstreamStackThemeConfig(logoV1))
+	if err != nil {
+		t.Fatalf("error creating: %s", err)
+	}
+	if state.ID != "test-stack" {
+		t.Fatalf("expected ID test-stack, got %q", state.ID)
+	}
+	for k, v := range map[string]string{
+		"organization_logo_s3_key": logoKey(logoV1),
+		"organization_logo_sha256": sha256Hex(logoV1),
+		"organization_logo_url":    "https://appstream.test/themes/test-stack/" + logoKey(logoV1),
+		"favicon_s3_key":           faviconKey,
+		"favicon_url":              "https://appstream.test/themes/test-stack/" + faviconKey,
+		"state":                    themeStateEnabled,
+	} {
+		if state.Attributes[k] != v {
+			t.Errorf("expected %s %q, got %q", k, v, state.Attributes[k])
+		}
+	}
+	if len(server.objects) != 2 {
+		t.Errorf("expected 2 uploaded objects, got %v", server.objects)
+	}
+
+	// A new logo is uploaded under a new key and the old object is removed.
+	state, err = testApplyAppstreamStackTheme(t, client, state, testAppstreamStackThemeConfig(logoV2))
+	if err != nil {
+		t.Fatalf("error updating: %s", err)
+	}
+	if v := state.Attributes["organization_logo_url"]; v != "https://appstream.test/themes/test-stack/"+logoKey(logoV2) {
+		t.Errorf("expected the new logo URL, got %q", v)
+	}
+	if _, ok := server.objects["themes/"+logoKey(logoV1)]; ok {
+		t.Error("expected the old logo to be deleted")
+	}
+	if _, ok := server.objects["themes/"+faviconKey]; !ok {
+		t.Error("expected the favicon to be kept")
+	}
+	if server.calls["PutObject"] != 3 {
+		t.Errorf("expected 3 PutObject calls, got %d", server.calls["PutObject"])
+	}
+
+	// A logo replaced out of band clears its hash on refresh, the next apply uploads it again.
+	server.themes["test-stack"].ThemeOrganizationLogoURL = aws.String("https://appstream.test/themes/test-stack/other.png")
+	state, err = r.RefreshWithoutUpgrade(state, client)
+	if err != nil {
+		t.Fatalf("error refreshing: %s", err)
+	}
+	if v := state.Attributes["organization_logo_sha256"]; v != "" {
+		t.Errorf("expected organization_logo_sha256 to be cleared, got %q", v)
+	}
+	if v := state.Attributes["favicon_sha256"]; v != sha256Hex(testThemeICO) {
+		t.Errorf("expected favicon_sha256 to be kept, got %q", v)
+	}
+	state, err = testApplyAppstreamStackTheme(t, client, state, testAppstreamStackThemeConfig(logoV2))
+	if err != nil {
+		t.Fatalf("error restoring the logo: %s", err)
+	}
+	if v := state.Attributes["organization_logo_url"]; v != "https://appstream.test/themes/test-stack/"+logoKey(logoV2) {
+		t.Errorf("expected the logo URL to be restored, got %q", v)
+	}
+	if v := state.Attributes["organization_logo_sha256"]; v != sha256Hex(logoV2) {
+		t.Errorf("expected organization_logo_sha256 to be restored, got %q", v)
+	}
+	if _, ok := server.objects["themes/"+logoKey(logoV2)]; !ok {
+		t.Error("expected the re-uploaded logo to be kept")
+	}
+	diff, err := r.Diff(state, terraform.NewResourceConfigRaw(testAppstreamStackThemeConfig(logoV2)), client)
+	if err != nil {
+		t.Fatalf("error planning: %s", err)
+	}
+	if !diff.Empty() {
+		t.Errorf("expected no diff after restoring the logo, got %#v", diff.Attributes)
+	}
+
+	if _, err := r.Apply(state, &terraform.InstanceDiff{Destroy: true}, client); err != nil {
+		t.Fatalf("error deleting: %s", err)
+	}
+	if _, ok := server.themes["test-stack"]; ok {
+		t.Error("expected the theme to be deleted")
+	}
+	if len(server.objects) != 0 {
+		t.Errorf("expected the images to be deleted, got %v", server.objects)
+	}
+}
+
+func TestResourceAppstreamStackTheme_failureRemovesUploads(t *testing.T) {
+	server := newTestAppstreamServer(t)
+	server.addStack(&appstream.Stack{Name: aws.String("test-stack")})
+	client := server.client(t)
+
+	server.fail["CreateThemeForStack"] = true
+	if _, err := testApplyAppstreamStackTheme(t, client, nil, testAppstreamStackThemeConfig(testThemePNG)); err == nil {
+		t.Fatal("expected the create to fail")
+	}
+	if len(server.objects) != 0 {
+		t.Errorf("expected the uploads to be deleted after a failed create, got %v", server.objects)
+	}
+
+	server.fail["CreateThemeForStack"] = false
+	state, err := testApplyAppstreamStackTheme(t, client, nil, testAppstreamStackThemeConfig(testThemePNG))
+	if err != nil {
+		t.Fatalf("error creating: %s", err)
+	}
+
+	server.fail["UpdateThemeForStack"] = true
+	if _, err := testApplyAppstreamStackTheme(t, client, state, testAppstreamStackThemeConfig(append(append([]byte{}, testThemePNG...), "v2"...))); err == nil {
+		t.Fatal("expected the update to fail")
+	}
+	if len(server.objects) != 2 {
+		t.Errorf("expected only the images in use to be kept after a failed update, got %v", server.objects)
+	}
+	for _, k := range []string{"organization_logo_s3_key", "favicon_s3_key"} {
+		if _, ok := server.objects["themes/"+state.Attributes[k]]; !ok {
+			t.Errorf("expected the object of %s to be kept", k)
+		}
+	}
+}
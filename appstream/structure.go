@@ -0,0 +1,209 @@
+package appstream
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/service/appstream"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+// vpcConfigSchema is the vpc_config block shared by fleets and image builders,
+// forceNew is set where the API cannot change the VPC of an existing resource.
+func vpcConfigSchema(forceNew bool) *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeList,
+		Optional: true,
+		ForceNew: forceNew,
+		MaxItems: 1,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"security_group_ids": {
+					Type:     schema.TypeSet,
+					Optional: true,
+					ForceNew: forceNew,
+					MaxItems: 5,
+					Elem: &schema.Schema{
+						Type:         schema.TypeString,
+						ValidateFunc: validation.StringMatch(regexp.MustCompile(`^sg-[0-9a-f]{8}([0-9a-f]{9})?$`), "must be a security group ID (sg-...)"),
+					},
+				},
+				"subnet_ids": {
+					Type:     schema.TypeSet,
+					Optional: true,
+					ForceNew: forceNew,
+					Elem: &schema.Schema{
+						Type:         schema.TypeString,
+						ValidateFunc: validation.StringMatch(regexp.MustCompile(`^subnet-[0-9a-f]{8}([0-9a-f]{9})?$`), "must be a subnet ID (subnet-...)"),
+					},
+				},
+			},
+		},
+	}
+}
+
+// domainInfoSchema is the domain_info block shared by fleets and image builders,
+// forceNew is set where the API cannot change the domain join of an existing resource.
+func domainInfoSchema(forceNew bool) *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeList,
+		Optional: true,
+		ForceNew: forceNew,
+		MaxItems: 1,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"directory_name": {
+					Type:     schema.TypeString,
+					Optional: true,
+					ForceNew: forceNew,
+				},
+				"organizational_unit_distinguished_name": {
+					Type:         schema.TypeString,
+					Optional:     true,
+					ForceNew:     forceNew,
+					ValidateFunc: validateLDAPDistinguishedName,
+				},
+			},
+		},
+	}
+}
+
+// accessEndpointsSchema is the access_endpoints block shared by stacks and image builders,
+// forceNew is set where the API cannot change the endpoints of an existing resource.
+func accessEndpointsSchema(forceNew bool) *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeSet,
+		Optional: true,
+		ForceNew: forceNew,
+		MaxItems: 4,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"endpoint_type": {
+					Type:         schema.TypeString,
+					Required:     true,
+					ForceNew:     forceNew,
+					ValidateFunc: validation.StringInSlice(appstream.AccessEndpointType_Values(), false),
+				},
+				"vpce_id": {
+					Type:         schema.TypeString,
+					Required:     true,
+					ForceNew:     forceNew,
+					ValidateFunc: validation.StringMatch(regexp.MustCompile(`^vpce-[0-9a-f]{8}([0-9a-f]{9})?$`), "must be a VPC endpoint ID (vpce-...)"),
+				},
+			},
+		},
+	}
+}
+
+func expandAccessEndpoints(accessEndpoints []interface{}) []*appstream.AccessEndpoint {
+	result := make([]*appstream.AccessEndpoint, 0, len(accessEndpoints))
+	for _, raw := range accessEndpoints {
+		attr, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		result = append(result, &appstream.AccessEndpoint{
+			EndpointType: aws.String(attr["endpoint_type"].(string)),
+			VpceId:       aws.String(attr["vpce_id"].(string)),
+		})
+	}
+	return result
+}
+
+func flattenAccessEndpoints(accessEndpoints []*appstream.AccessEndpoint) []interface{} {
+	result := make([]interface{}, 0, len(accessEndpoints))
+	for _, v := range accessEndpoints {
+		result = append(result, map[string]interface{}{
+			"endpoint_type": aws.StringValue(v.EndpointType),
+			"vpce_id":       aws.StringValue(v.VpceId),
+		})
+	}
+	return result
+}
+
+func expandDomainJoinInfo(domainInfos []interface{}) *appstream.DomainJoinInfo {
+	if len(domainInfos) == 0 || domainInfos[0] == nil {
+		return nil
+	}
+
+	attr := domainInfos[0].(map[string]interface{})
+	domainJoinInfo := &appstream.DomainJoinInfo{}
+	if v, ok := attr["directory_name"].(string); ok && v != "" {
+		domainJoinInfo.DirectoryName = aws.String(v)
+	}
+	if v, ok := attr["organizational_unit_distinguished_name"].(string); ok && v != "" {
+		domainJoinInfo.OrganizationalUnitDistinguishedName = aws.String(v)
+	}
+	return domainJoinInfo
+}
+
+func flattenDomainJoinInfo(domainJoinInfo *appstream.DomainJoinInfo) []interface{} {
+	if domainJoinInfo == nil {
+		return nil
+	}
+
+	attr := map[string]interface{}{}
+	attr["directory_name"] = aws.StringValue(domainJoinInfo.DirectoryName)
+	attr["organizational_unit_distinguished_name"] = aws.StringValue(domainJoinInfo.OrganizationalUnitDistinguishedName)
+	return []interface{}{attr}
+}
+
+func expandVpcConfig(vpcConfigs []interface{}) *appstream.VpcConfig {
+	if len(vpcConfigs) == 0 || vpcConfigs[0] == nil {
+		return nil
+	}
+
+	attr := vpcConfigs[0].(map[string]interface{})
+	vpcConfig := &appstream.VpcConfig{}
+	if v, ok := attr["security_group_ids"].(*schema.Set); ok && v.Len() > 0 {
+		vpcConfig.SecurityGroupIds = expandStringSet(v)
+	}
+	if v, ok := attr["subnet_ids"].(*schema.Set); ok && v.Len() > 0 {
+		vpcConfig.SubnetIds = expandStringSet(v)
+	}
+	return vpcConfig
+}
+
+func flattenVpcConfig(vpcConfig *appstream.VpcConfig) []interface{} {
+	if vpcConfig == nil {
+		return nil
+	}
+
+	attr := map[string]interface{}{}
+	attr["security_group_ids"] = flattenStringList(vpcConfig.SecurityGroupIds)
+	attr["subnet_ids"] = flattenStringList(vpcConfig.SubnetIds)
+	return []interface{}{attr}
+}
+
+func expandStringSet(set *schema.Set) []*string {
+	result := make([]*string, 0, set.Len())
+	for _, v := range set.List() {
+		result = append(result, aws.String(v.(string)))
+	}
+	return result
+}
+
+func flattenStringList(list []*string) []interface{} {
+	result := make([]interface{}, 0, len(list))
+	for _, v := range list {
+		result = append(result, aws.StringValue(v))
+	}
+	return result
+}
+
+// appstreamNameFromArn returns the name in an AppStream ARN of the given resource type,
+// e.g. "stack" for arn:aws:appstream:<region>:<account>:stack/<name>.
+func appstreamNameFromArn(v, resourceType string) (string, error) {
+	parsed, err := arn.Parse(v)
+	if err != nil {
+		return "", err
+	}
+	if parsed.Service != "appstream" || !strings.HasPrefix(parsed.Resource, resourceType+"/") {
+		return "", fmt.Errorf("%q is not an AppStream %s ARN", v, resourceType)
+	}
+	return strings.TrimPrefix(parsed.Resource, resourceType+"/"), nil
+}
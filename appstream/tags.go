@@ -0,0 +1,133 @@
+package appstream
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/appstream"
+)
+
+// Tags the provider keeps on AppStream resources for its own bookkeeping. They live in AWS
+// so they survive state loss and imports, and are filtered out of the tags attribute.
+const (
+	// deletionProtectionTagKey is "true" while deletion_protection is enabled.
+	deletionProtectionTagKey = "terraform-provider-appstream:deletion-protection"
+
+	// managedByTagKey holds the creation_token of fleets and image builders created by the
+	// provider, so an interrupted create of the same configuration can adopt them on the
+	// next apply.
+	managedByTagKey = "terraform-provider-appstream:managed-by"
+)
+
+var providerTagKeys = []string{
+	deletionProtectionTagKey,
+	managedByTagKey,
+}
+
+// IgnoreProvider returns tags without the keys the provider manages itself.
+func (tags KeyValueTags) IgnoreProvider() KeyValueTags {
+	result := make(KeyValueTags)
+
+	for k, v := range tags {
+		if !stringInSlice(k, providerTagKeys) {
+			result[k] = v
+		}
+	}
+
+	return result
+}
+
+// deletionProtectionTags returns the provider tags recording deletion protection.
+func deletionProtectionTags(enabled bool) KeyValueTags {
+	if !enabled {
+		return make(KeyValueTags)
+	}
+	return New(map[string]string{deletionProtectionTagKey: "true"})
+}
+
+// defaultCreationToken is the creation token of a fleet or image builder without a
+// configured one. It only depends on the name, so the apply after an interrupted create
+// adopts what that create left behind.
+func defaultCreationToken(name string) string {
+	return name
+}
+
+// managedByTags returns the provider tag recording the creation token of a resource.
+func managedByTags(token string) KeyValueTags {
+	return New(map[string]string{managedByTagKey: token})
+}
+
+// managedByToken returns the creation token the resource was tagged with, if any.
+func managedByToken(conn *appstream.AppStream, identifier string) (string, error) {
+	resp, err := conn.ListTagsForResource(&appstream.ListTagsForResourceInput{
+		ResourceArn: aws.String(identifier),
+	})
+	if err != nil {
+		log.Printf("[ERROR] Error listing tags: %s", err)
+		return "", err
+	}
+
+	return aws.StringValue(resp.Tags[managedByTagKey]), nil
+}
+
+// updateManagedBy replaces the creation token a resource is tagged with.
+func updateManagedBy(conn *appstream.AppStream, identifier string, token string) error {
+	_, err := conn.TagResource(&appstream.TagResourceInput{
+		ResourceArn: aws.String(identifier),
+		Tags:        Tags(managedByTags(token)),
+	})
+	if err != nil {
+		return fmt.Errorf("error updating creation token of resource (%s): %w", identifier, err)
+	}
+	return nil
+}
+
+// updateDeletionProtection adds or removes the deletion protection tag of a resource.
+func updateDeletionProtection(conn *appstream.AppStream, identifier string, enabled bool) error {
+	if enabled {
+		_, err := conn.TagResource(&appstream.TagResourceInput{
+			ResourceArn: aws.String(identifier),
+			Tags:        Tags(deletionProtectionTags(true)),
+		})
+		if err != nil {
+			return fmt.Errorf("error enabling deletion protection on resource (%s): %w", identifier, err)
+		}
+		return nil
+	}
+
+	_, err := conn.UntagResource(&appstream.UntagResourceInput{
+		ResourceArn: aws.String(identifier),
+		TagKeys:     aws.StringSlice([]string{deletionProtectionTagKey}),
+	})
+	if err != nil {
+		return fmt.Errorf("error disabling deletion protection on resource (%s): %w", identifier, err)
+	}
+	return nil
+}
+
+// checkDeletionProtection refuses to go on while the resource carries the deletion protection tag.
+func checkDeletionProtection(conn *appstream.AppStream, identifier string) error {
+	resp, err := conn.ListTagsForResource(&appstream.ListTagsForResourceInput{
+		ResourceArn: aws.String(identifier),
+	})
+	if err != nil {
+		log.Printf("[ERROR] Error listing tags: %s", err)
+		return err
+	}
+
+	if aws.StringValue(resp.Tags[deletionProtectionTagKey]) == "true" {
+		return fmt.Errorf("resource (%s) has deletion_protection enabled, set it to false and apply before destroying", identifier)
+	}
+	return nil
+}
+
+// tagsMatch reports whether tags carries every key and value of filter.
+func tagsMatch(tags map[string]*string, filter map[string]interface{}) bool {
+	for k, v := range filter {
+		if tag, ok := tags[k]; !ok || aws.StringValue(tag) != v.(string) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,178 @@
+package appstream
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/appstream"
+)
+
+// The stack theme operations are not part of any aws-sdk-go v1 release. They are sent
+// through the AppStream client's JSON-RPC handlers, so signing, retries, endpoints and
+// error codes behave as for the generated operations.
+
+const (
+	themeStateEnabled  = "ENABLED"
+	themeStateDisabled = "DISABLED"
+
+	themeAttributeFooterLinks = "FOOTER_LINKS"
+)
+
+func themeState_Values() []string {
+	return []string{themeStateEnabled, themeStateDisabled}
+}
+
+func themeStyling_Values() []string {
+	return []string{"LIGHT_BLUE", "BLUE", "PINK", "RED"}
+}
+
+type appstreamS3Location struct {
+	_ struct{} `type:"structure"`
+
+	S3Bucket *string `min:"1" type:"string" required:"true"`
+	S3Key    *string `min:"1" type:"string"`
+}
+
+type appstreamThemeFooterLink struct {
+	_ struct{} `type:"structure"`
+
+	DisplayName   *string `min:"1" type:"string"`
+	FooterLinkURL *string `min:"1" type:"string"`
+}
+
+type appstreamTheme struct {
+	_ struct{} `type:"structure"`
+
+	CreatedTime              *time.Time                  `type:"timestamp"`
+	StackName                *string                     `min:"1" type:"string"`
+	State                    *string                     `type:"string"`
+	ThemeFaviconURL          *string                     `min:"1" type:"string"`
+	ThemeFooterLinks         []*appstreamThemeFooterLink `type:"list"`
+	ThemeOrganizationLogoURL *string                     `min:"1" type:"string"`
+	ThemeStyling             *string                     `type:"string"`
+	ThemeTitleText           *string                     `min:"1" type:"string"`
+}
+
+func (s appstreamTheme) String() string {
+	return awsutil.Prettify(s)
+}
+
+type createThemeForStackInput struct {
+	_ struct{} `type:"structure"`
+
+	FaviconS3Location          *appstreamS3Location        `type:"structure" required:"true"`
+	FooterLinks                []*appstreamThemeFooterLink `type:"list"`
+	OrganizationLogoS3Location *appstreamS3Location        `type:"structure" required:"true"`
+	StackName                  *string                     `min:"1" type:"string" required:"true"`
+	ThemeStyling               *string                     `type:"string" required:"true"`
+	TitleText                  *string                     `min:"1" type:"string" required:"true"`
+}
+
+func (s createThemeForStackInput) String() string {
+	return awsutil.Prettify(s)
+}
+
+type createThemeForStackOutput struct {
+	_ struct{} `type:"structure"`
+
+	Theme *appstreamTheme `type:"structure"`
+}
+
+func (s createThemeForStackOutput) String() string {
+	return awsutil.Prettify(s)
+}
+
+type describeThemeForStackInput struct {
+	_ struct{} `type:"structure"`
+
+	StackName *string `min:"1" type:"string" required:"true"`
+}
+
+func (s describeThemeForStackInput) String() string {
+	return awsutil.Prettify(s)
+}
+
+type describeThemeForStackOutput struct {
+	_ struct{} `type:"structure"`
+
+	Theme *appstreamTheme `type:"structure"`
+}
+
+func (s describeThemeForStackOutput) String() string {
+	return awsutil.Prettify(s)
+}
+
+type updateThemeForStackInput struct {
+	_ struct{} `type:"structure"`
+
+	AttributesToDelete         []*string                   `type:"list"`
+	FaviconS3Location          *appstreamS3Location        `type:"structure"`
+	FooterLinks                []*appstreamThemeFooterLink `type:"list"`
+	OrganizationLogoS3Location *appstreamS3Location        `type:"structure"`
+	StackName                  *string                     `min:"1" type:"string" required:"true"`
+	State                      *string                     `type:"string"`
+	ThemeStyling               *string                     `type:"string"`
+	TitleText                  *string                     `min:"1" type:"string"`
+}
+
+func (s updateThemeForStackInput) String() string {
+	return awsutil.Prettify(s)
+}
+
+type updateThemeForStackOutput struct {
+	_ struct{} `type:"structure"`
+
+	Theme *appstreamTheme `type:"structure"`
+}
+
+func (s updateThemeForStackOutput) String() string {
+	return awsutil.Prettify(s)
+}
+
+type deleteThemeForStackInput struct {
+	_ struct{} `type:"structure"`
+
+	StackName *string `min:"1" type:"string" required:"true"`
+}
+
+func (s deleteThemeForStackInput) String() string {
+	return awsutil.Prettify(s)
+}
+
+type deleteThemeForStackOutput struct {
+	_ struct{} `type:"structure"`
+}
+
+func (s deleteThemeForStackOutput) String() string {
+	return awsutil.Prettify(s)
+}
+
+func sendAppstreamRequest(conn *appstream.AppStream, name string, input, output interface{}) error {
+	req := conn.NewRequest(&request.Operation{
+		Name:       name,
+		HTTPMethod: "POST",
+		HTTPPath:   "/",
+	}, input, output)
+	return req.Send()
+}
+
+func createThemeForStack(conn *appstream.AppStream, input *createThemeForStackInput) (*createThemeForStackOutput, error) {
+	output := &createThemeForStackOutput{}
+	return output, sendAppstreamRequest(conn, "CreateThemeForStack", input, output)
+}
+
+func describeThemeForStack(conn *appstream.AppStream, input *describeThemeForStackInput) (*describeThemeForStackOutput, error) {
+	output := &describeThemeForStackOutput{}
+	return output, sendAppstreamRequest(conn, "DescribeThemeForStack", input, output)
+}
+
+func updateThemeForStack(conn *appstream.AppStream, input *updateThemeForStackInput) (*updateThemeForStackOutput, error) {
+	output := &updateThemeForStackOutput{}
+	return output, sendAppstreamRequest(conn, "UpdateThemeForStack", input, output)
+}
+
+func deleteThemeForStack(conn *appstream.AppStream, input *deleteThemeForStackInput) (*deleteThemeForStackOutput, error) {
+	output := &deleteThemeForStackOutput{}
+	return output, sendAppstreamRequest(conn, "DeleteThemeForStack", input, output)
+}
@@ -0,0 +1,180 @@
+package appstream
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	// Ship the IANA database so timezone validation does not depend on the host.
+	_ "time/tzdata"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+var (
+	scheduleAtRegexp   = regexp.MustCompile(`^at\(\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\)$`)
+	scheduleRateRegexp = regexp.MustCompile(`^rate\(([1-9][0-9]*) (minute|minutes|hour|hours|day|days)\)$`)
+	scheduleCronRegexp = regexp.MustCompile(`^cron\(\S+( \S+){5}\)$`)
+
+	appstreamNameRegexp     = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]{0,100}$`)
+	appstreamImageArnRegexp = regexp.MustCompile(`^arn:aws(-[a-z]+)*:appstream:[a-z0-9-]+:([0-9]{12})?:image/[a-zA-Z0-9][a-zA-Z0-9_.-]{0,100}$`)
+	iamRoleArnRegexp        = regexp.MustCompile(`^arn:aws(-[a-z]+)*:iam::[0-9]{12}:role/.+$`)
+
+	domainNameRegexp = regexp.MustCompile(`^(?:[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z0-9][a-z0-9-]{0,61}[a-z0-9]$`)
+
+	ldapAttributeTypeRegexp = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9-]*|[0-9]+(\.[0-9]+)*)$`)
+)
+
+// validateAppstreamName accepts the names AppStream allows for fleets, stacks and image builders.
+func validateAppstreamName(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	if !appstreamNameRegexp.MatchString(value) {
+		errors = append(errors, fmt.Errorf(
+			"%q must start with a letter or digit and contain at most 100 more letters, digits, '_', '.' or '-', got %q", k, value))
+	}
+	return
+}
+
+// validateAppstreamImageArn accepts AppStream image ARNs, including the account-less ARNs of AWS base images.
+func validateAppstreamImageArn(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	if !appstreamImageArnRegexp.MatchString(value) {
+		errors = append(errors, fmt.Errorf("%q must be an AppStream image ARN (arn:aws:appstream:<region>:<account>:image/<name>), got %q", k, value))
+	}
+	return
+}
+
+func validateIamRoleArn(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	if !iamRoleArnRegexp.MatchString(value) {
+		errors = append(errors, fmt.Errorf("%q must be an IAM role ARN (arn:aws:iam::<account>:role/<name>), got %q", k, value))
+	}
+	return
+}
+
+// validateAppstreamURL accepts the http and https URLs stacks redirect users to.
+func validateAppstreamURL(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	if value == "" {
+		return
+	}
+	if len(value) > 1000 {
+		errors = append(errors, fmt.Errorf("%q must be at most 1000 characters, got %d", k, len(value)))
+		return
+	}
+	return validation.IsURLWithHTTPorHTTPS(v, k)
+}
+
+// validateAppautoscalingScheduleExpression accepts the at(), rate() and cron() forms
+// understood by Application Auto Scaling scheduled actions.
+func validateAppautoscalingScheduleExpression(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	if scheduleAtRegexp.MatchString(value) {
+		if _, err := time.Parse("2006-01-02T15:04:05", value[3:len(value)-1]); err != nil {
+			errors = append(errors, fmt.Errorf("%q contains an invalid at() timestamp: %s", k, err))
+		}
+		return
+	}
+
+	if m := scheduleRateRegexp.FindStringSubmatch(value); m != nil {
+		if m[1] == "1" && m[2][len(m[2])-1] == 's' {
+			errors = append(errors, fmt.Errorf("%q must use the singular unit for a rate of 1, got %q", k, value))
+		}
+		if m[1] != "1" && m[2][len(m[2])-1] != 's' {
+			errors = append(errors, fmt.Errorf("%q must use the plural unit for a rate above 1, got %q", k, value))
+		}
+		return
+	}
+
+	if scheduleCronRegexp.MatchString(value) {
+		return
+	}
+
+	errors = append(errors, fmt.Errorf(
+		"%q must be at(yyyy-mm-ddThh:mm:ss), rate(value unit) or cron(minutes hours day-of-month month day-of-week year), got %q", k, value))
+	return
+}
+
+// validateTimezone accepts IANA time zone names such as "Europe/Zurich".
+func validateTimezone(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	if _, err := time.LoadLocation(value); err != nil {
+		errors = append(errors, fmt.Errorf("%q must be an IANA time zone name, got %q", k, value))
+	}
+	return
+}
+
+// validateDomainName accepts lower-case DNS names such as "portal.example.com".
+func validateDomainName(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	if len(value) > 128 {
+		errors = append(errors, fmt.Errorf("%q must be at most 128 characters, got %d", k, len(value)))
+		return
+	}
+	if !domainNameRegexp.MatchString(value) {
+		errors = append(errors, fmt.Errorf("%q must be a lower-case domain name such as portal.example.com, got %q", k, value))
+	}
+	return
+}
+
+// validateDuration accepts Go durations such as "15m" or "1h30m".
+func validateDuration(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		errors = append(errors, fmt.Errorf("%q must be a duration such as 15m or 1h30m, got %q", k, value))
+		return
+	}
+	if d <= 0 {
+		errors = append(errors, fmt.Errorf("%q must be positive, got %q", k, value))
+	}
+	return
+}
+
+// validateLDAPDistinguishedName accepts RFC 4514 distinguished names such as
+// "OU=AppStream,DC=corp,DC=example,DC=com".
+func validateLDAPDistinguishedName(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	if value == "" {
+		return
+	}
+
+	for _, rdn := range splitUnescaped(value, ",;") {
+		for _, ava := range splitUnescaped(rdn, "+") {
+			parts := strings.SplitN(strings.TrimSpace(ava), "=", 2)
+			if len(parts) != 2 || !ldapAttributeTypeRegexp.MatchString(parts[0]) || parts[1] == "" {
+				errors = append(errors, fmt.Errorf("%q must be an LDAP distinguished name such as OU=AppStream,DC=corp,DC=example,DC=com, got %q", k, value))
+				return
+			}
+		}
+	}
+	return
+}
+
+// splitUnescaped splits s around the separators that are not escaped with a backslash.
+func splitUnescaped(s string, separators string) []string {
+	parts := make([]string, 0)
+	escaped := false
+	start := 0
+	for i, c := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case strings.ContainsRune(separators, c):
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
@@ -0,0 +1,81 @@
+package appstream
+
+import (
+	"testing"
+)
+
+func TestValidateAppautoscalingScheduleExpression(t *testing.T) {
+	cases := []struct {
+		value string
+		valid bool
+	}{
+		{"at(2026-11-01T08:00:00)", true},
+		{"at(2026-13-01T08:00:00)", false},
+		{"at(2026-11-01 08:00:00)", false},
+		{"rate(1 hour)", true},
+		{"rate(15 minutes)", true},
+		{"rate(1 hours)", false},
+		{"rate(2 day)", false},
+		{"rate(0 days)", false},
+		{"rate(1 week)", false},
+		{"cron(0 8 ? * MON-FRI *)", true},
+		{"cron(0 8 * *)", false},
+		{"every monday", false},
+		{"", false},
+	}
+
+	for _, tc := range cases {
+		_, errs := validateAppautoscalingScheduleExpression(tc.value, "schedule")
+		if (len(errs) == 0) != tc.valid {
+			t.Errorf("%q: expected valid %t, got %v", tc.value, tc.valid, errs)
+		}
+	}
+}
+
+func TestValidateTimezone(t *testing.T) {
+	cases := []struct {
+		value string
+		valid bool
+	}{
+		{"UTC", true},
+		{"Europe/Oslo", true},
+		{"America/Argentina/Buenos_Aires", true},
+		{"Europe/Atlantis", false},
+		{"CEST+1", false},
+	}
+
+	for _, tc := range cases {
+		_, errs := validateTimezone(tc.value, "timezone")
+		if (len(errs) == 0) != tc.valid {
+			t.Errorf("%q: expected valid %t, got %v", tc.value, tc.valid, errs)
+		}
+	}
+}
+
+func TestValidateLDAPDistinguishedName(t *testing.T) {
+	cases := []struct {
+		value string
+		valid bool
+	}{
+		{"", true},
+		{"OU=AppStream,DC=corp,DC=example,DC=com", true},
+		{"OU=AppStream;DC=corp;DC=example", true},
+		{"OU=R\\+D,DC=corp,DC=example", true},
+		{"OU=Sales\\, EMEA,DC=corp,DC=example", true},
+		{"CN=Kiosk+UID=42,OU=AppStream,DC=corp", true},
+		{"2.5.4.11=AppStream,DC=corp", true},
+		{"OU=R+D,DC=corp,DC=example", false},
+		{"OU=Sales, EMEA,DC=corp", false},
+		{"OU=AppStream,,DC=corp", false},
+		{"OU=,DC=corp", false},
+		{"AppStream", false},
+		{"1OU=AppStream", false},
+	}
+
+	for _, tc := range cases {
+		_, errs := validateLDAPDistinguishedName(tc.value, "organizational_unit_distinguished_name")
+		if (len(errs) == 0) != tc.valid {
+			t.Errorf("%q: expected valid %t, got %v", tc.value, tc.valid, errs)
+		}
+	}
+}
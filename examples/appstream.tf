@@ -1,64 +1,125 @@
-provider "appstream" {
-  version = "v1.0.8"
-  assume_role {
-    role_arn = var.assume_role_arn
-  }
-  region = var.region_primary
-}
-
-
-resource "appstream_image_builder" "test-image-builder" {
-  name                           = "test-image-builder"
-  appstream_agent_version        = "LATEST"
-  description                    = "test image builder"
-  display_name                   = "test-image-builder"
-  enable_default_internet_access = true
-  image_name                     = "Base-Image-Builder-05-02-2018"
-  instance_type                  = "stream.standard.large"
-  vpc_config {
-    security_group_ids = "sg-b5af81d3"
-    subnet_ids         = "subnet-7a5f4b51"
-  }
-  state = "RUNNING"
-}
-
-
-resource "appstream_stack" "test-stack" {
-  name         = "test-stack"
-  description  = "appstream test stack"
-  display_name = "test-stack"
-  feedback_url = "http://example1.com"
-  redirect_url = "http://example1.com"
-  storage_connectors {
-    connector_type = "HOMEFOLDERS"
-  }
-  tags {
-    Env  = "lab"
-    Role = "appstream-stack"
-  }
-}
-
-resource "appstream_fleet" "test-fleet" {
-  name       = "test-fleet"
-  stack_name = appstream_stack.test-stack.name
-  compute_capacity {
-    desired_instances = 1
-  }
-  description                    = "test fleet"
-  disconnect_timeout             = 300
-  display_name                   = "test-fleet"
-  enable_default_internet_access = true
-  fleet_type                     = "ON_DEMAND"
-  image_name                     = "arn:aws:appstream:eu-west-1:1231241241:image/Base-Image-Builder-05-02-2018"
-  instance_type                  = "stream.standard.large"
-  max_user_duration              = 600
-  vpc_config {
-    security_group_ids = "sg-b5af81d3"
-    subnet_ids         = "subnet-7a5f4b51,subnet-7a5f1231"
-  }
-  tags {
-    Env  = "lab"
-    Role = "appstream-fleet"
-  }
-  state = "RUNNING"
-}
+provider "appstream" {
+  version = "v1.0.8"
+  assume_role {
+    role_arn = var.assume_role_arn
+  }
+  region = var.region_primary
+}
+
+
+resource "appstream_image_builder" "test-image-builder" {
+  name                           = "test-image-builder"
+  appstream_agent_version        = "LATEST"
+  description                    = "test image builder"
+  display_name                   = "test-image-builder"
+  enable_default_internet_access = true
+  image_name                     = "Base-Image-Builder-05-02-2018"
+  instance_type                  = "stream.standard.large"
+  vpc_config {
+    security_group_ids = ["sg-b5af81d3"]
+    subnet_ids         = ["subnet-7a5f4b51"]
+  }
+  desired_state = "RUNNING"
+}
+
+
+resource "appstream_stack" "test-stack" {
+  name         = "test-stack"
+  description  = "appstream test stack"
+  display_name = "test-stack"
+  feedback_url = "http://example1.com"
+  redirect_url = "http://example1.com"
+  storage_connectors {
+    connector_type = "HOMEFOLDERS"
+  }
+  tags {
+    Env  = "lab"
+    Role = "appstream-stack"
+  }
+}
+
+resource "appstream_fleet" "test-fleet" {
+  name       = "test-fleet"
+  stack_name = appstream_stack.test-stack.name
+  compute_capacity {
+    desired_instances = 1
+  }
+  capacity_managed_externally    = true
+  description                    = "test fleet"
+  disconnect_timeout             = 300
+  display_name                   = "test-fleet"
+  enable_default_internet_access = true
+  fleet_type                     = "ON_DEMAND"
+  image_name                     = "arn:aws:appstream:eu-west-1:1231241241:image/Base-Image-Builder-05-02-2018"
+  instance_type                  = "stream.standard.large"
+  max_user_duration              = 600
+  vpc_config {
+    security_group_ids = ["sg-b5af81d3"]
+    subnet_ids         = ["subnet-7a5f4b51", "subnet-7a5f1231"]
+  }
+  tags {
+    Env  = "lab"
+    Role = "appstream-fleet"
+  }
+  desired_state = "RUNNING"
+}
+
+resource "appstream_fleet_scalable_target" "test-fleet" {
+  fleet_name   = appstream_fleet.test-fleet.name
+  min_capacity = 1
+  max_capacity = 10
+}
+
+resource "appstream_fleet_scaling_policy" "test-fleet" {
+  name       = "test-fleet-utilization"
+  fleet_name = appstream_fleet_scalable_target.test-fleet.fleet_name
+  target_tracking_scaling_policy_configuration {
+    target_value       = 75
+    scale_in_cooldown  = 600
+    scale_out_cooldown = 300
+  }
+}
+
+resource "appstream_fleet_scheduled_action" "test-fleet-morning" {
+  name       = "test-fleet-morning"
+  fleet_name = appstream_fleet_scalable_target.test-fleet.fleet_name
+  schedule   = "cron(0 7 ? * MON-FRI *)"
+  timezone   = "Europe/Zurich"
+  scalable_target_action {
+    min_capacity = 5
+    max_capacity = 200
+  }
+}
+
+resource "appstream_fleet_scheduled_action" "test-fleet-evening" {
+  name       = "test-fleet-evening"
+  fleet_name = appstream_fleet_scalable_target.test-fleet.fleet_name
+  schedule   = "cron(0 19 ? * MON-FRI *)"
+  timezone   = "Europe/Zurich"
+  scalable_target_action {
+    min_capacity = 0
+    max_capacity = 5
+  }
+}
+
+data "appstream_instance_types" "smallest-8gb" {
+  fleet_type     = "ON_DEMAND"
+  platform       = "WINDOWS_SERVER_2019"
+  min_memory_gib = 8
+  gpu            = false
+}
+
+output "smallest_8gb_instance_type" {
+  value = data.appstream_instance_types.smallest-8gb.names[0]
+}
+
+data "appstream_fleets" "running" {
+  state = "RUNNING"
+}
+
+output "fleets_on_outdated_image" {
+  value = [
+    for i, name in data.appstream_fleets.running.names : name
+    if data.appstream_fleets.running.image_arns[i] != appstream_fleet.test-fleet.image_arn
+  ]
+}
@@ -3,7 +3,7 @@ module github.com/arnvid/terraform-provider-appstream
 go 1.16
 
 require (
-	github.com/aws/aws-sdk-go v1.38.61
+	github.com/aws/aws-sdk-go v1.55.8
 	github.com/hashicorp/aws-sdk-go-base v0.5.0
 	github.com/hashicorp/terraform-plugin-sdk v1.17.2
 	github.com/mitchellh/go-homedir v1.1.0